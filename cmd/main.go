@@ -1,10 +1,37 @@
 package main
 
-import "github.com/BabyJhon/medods-test-task/internal/app"
+import (
+	"fmt"
+	"os"
+
+	"github.com/BabyJhon/medods-test-task/internal/app"
+)
 
 // @title Medods Test Task API
 // @version 1.0
 // @description API для аутентификации пользователей
+// @BasePath /api/v1
 func main() {
-	app.Run()
+	if len(os.Args) < 2 {
+		app.Run()
+		return
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "migrate":
+		err = app.Migrate(os.Args[2:])
+	case "sessions":
+		err = app.Sessions(os.Args[2:])
+	case "tokens":
+		err = app.Tokens(os.Args[2:])
+	case "keys":
+		err = app.Keys(os.Args[2:])
+	default:
+		err = fmt.Errorf("unknown command %q, available commands: migrate, sessions, tokens, keys", os.Args[1])
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 }
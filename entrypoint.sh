@@ -7,7 +7,7 @@ until pg_isready -h db -U postgres; do
 done
 
 echo "ptrforming migrations"
-goose -dir /app/migrations postgres "user=postgres password=$DB_PASSWORD host=db dbname=postgres sslmode=disable" up
+/app/bin migrate up
 
 echo "Service started"
 exec "$@"
\ No newline at end of file
@@ -2,16 +2,14 @@ package app
 
 import (
 	"context"
-	"net/http"
+	"fmt"
 	"os"
 	"os/signal"
 	"syscall"
 
 	"github.com/BabyJhon/medods-test-task/internal/handlers"
-	"github.com/BabyJhon/medods-test-task/internal/repo"
 	"github.com/BabyJhon/medods-test-task/internal/service"
-	"github.com/BabyJhon/medods-test-task/pkg/httpserver"
-	"github.com/BabyJhon/medods-test-task/pkg/postgres"
+	"github.com/BabyJhon/medods-test-task/pkg/lifecycle"
 	"github.com/joho/godotenv"
 	"github.com/sirupsen/logrus"
 )
@@ -19,47 +17,114 @@ import (
 func Run() {
 	logrus.SetFormatter(new(logrus.JSONFormatter))
 
-	if err := godotenv.Load(); err != nil {
-		logrus.Fatalf("error loading env vars: %s", err.Error())
+	if err := loadEnv(); err != nil {
+		logrus.Fatal(err.Error())
+	}
+	if err := setupLogging(); err != nil {
+		logrus.Fatal(err.Error())
+	}
+
+	lifecycleCfg, err := lifecycleConfig()
+	if err != nil {
+		logrus.Fatal(err.Error())
 	}
+	lc := lifecycle.New(lifecycleCfg)
 
-	pool, err := postgres.NewPG(context.Background(), postgres.Config{
-		Host:     os.Getenv("PG_HOST"),
-		Port:     os.Getenv("PG_PORT"),
-		Username: os.Getenv("PG_USER"),
-		Password: os.Getenv("DB_PASSWORD"),
-		DBName:   os.Getenv("PG_DATABASE_NAME"),
-		SSLMode:  os.Getenv("PG_SSLMODE"),
+	store, err := openStorage(context.Background())
+	if err != nil {
+		logrus.Fatal(err.Error())
+	}
+	lc.Add(lifecycle.Component{
+		Name: "storage",
+		Stop: func(context.Context) error {
+			store.close()
+			return nil
+		},
 	})
+
+	if store.autoMigrate {
+		results, err := store.migrator.Up(context.Background())
+		if err != nil {
+			logrus.Fatalf("failed migrate db: %s", err.Error())
+		}
+		logrus.Printf("applied %d migrations", len(results))
+	}
+
+	signingKeys, err := service.LoadSigningKeys(os.Getenv("OIDC_SIGNING_KEY_FILE"))
+	if err != nil {
+		logrus.Fatalf("failed load signing key: %s", err.Error())
+	}
+
+	messages, err := localizer()
+	if err != nil {
+		logrus.Fatal(err.Error())
+	}
+	webhookMessages, err := webhookTranslator(messages)
+	if err != nil {
+		logrus.Fatal(err.Error())
+	}
+
+	services := service.NewService(store.repos, signingKeys, webhookMessages)
+
+	cookies, err := cookiePolicy()
 	if err != nil {
-		logrus.Fatalf("failed init db: %s", err.Error())
+		logrus.Fatal(err.Error())
 	}
 
-	defer pool.Close()
+	csrf, err := csrfGuard()
+	if err != nil {
+		logrus.Fatal(err.Error())
+	}
 
-	repos := repo.NewRepository(pool)
+	cors, err := corsRoutes()
+	if err != nil {
+		logrus.Fatal(err.Error())
+	}
 
-	services := service.NewService(repos)
+	legacy, err := legacyAPI()
+	if err != nil {
+		logrus.Fatal(err.Error())
+	}
 
-	handlers := handlers.NewHandler(services)
+	handlers := handlers.NewHandler(services, cookies, csrf, cors, messages, legacy, lc)
 
-	srv := new(httpserver.Server)
+	publicTLS, err := tlsConfig("")
+	if err != nil {
+		logrus.Fatal(err.Error())
+	}
+	adminTLS, err := tlsConfig("ADMIN_")
+	if err != nil {
+		logrus.Fatal(err.Error())
+	}
 
-	go func() {
-		if err := srv.Run(os.Getenv("PORT"), handlers.InitRoutes()); err != http.ErrServerClosed {
-			logrus.Fatalf("error occured while running server: %s", err.Error())
-		}
-	}()
+	// Вебхуки отправляются из обработчиков запросов, поэтому ожидаются после остановки серверов.
+	lc.Add(lifecycle.Component{
+		Name: "webhooks",
+		Stop: services.Webhooks.Drain,
+	})
 
-	logrus.Print("API started")
+	if adminPort := os.Getenv("ADMIN_PORT"); adminPort != "" {
+		lc.Add(serverComponent("admin API", lc, adminPort, handlers.InitAdminRoutes(), adminTLS))
+	} else {
+		logrus.Warn("ADMIN_PORT is not set, admin API is disabled")
+	}
 
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGTERM, syscall.SIGINT)
-	<-quit
+	lc.Add(serverComponent("API", lc, os.Getenv("PORT"), handlers.InitRoutes(), publicTLS))
+	if publicTLS == nil {
+		logrus.Warn("TLS_CERT_FILE is not set, API is served over plain HTTP and browsers drop Secure cookies")
+	}
 
-	logrus.Print("shutting down")
-	if err := srv.ShutDown(context.Background()); err != nil {
-		logrus.Errorf("error while server shutting down: %s", err.Error())
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	if err := lc.Run(ctx); err != nil {
+		logrus.Fatal(err.Error())
 	}
+}
 
+func loadEnv() error {
+	if err := godotenv.Load(); err != nil {
+		return fmt.Errorf("error loading env vars: %w", err)
+	}
+	return nil
 }
@@ -0,0 +1,54 @@
+package app
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/BabyJhon/medods-test-task/internal/entity"
+)
+
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	return fs
+}
+
+// subcommand splits args into the subcommand name and its arguments.
+func subcommand(args []string, usage string) (string, []string, error) {
+	if len(args) == 0 {
+		return "", nil, fmt.Errorf("usage: %s", usage)
+	}
+	return args[0], args[1:], nil
+}
+
+func printSessions(w io.Writer, sessions []entity.Session) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tSTATE\tCREATED AT\tEXPIRES AT\tIP\tUSER AGENT\tSCOPE")
+	for _, session := range sessions {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			session.ID,
+			sessionState(session),
+			session.CreatedAt.Format(time.RFC3339),
+			session.ExpiresAt.Format(time.RFC3339),
+			session.IP,
+			session.UserAgent,
+			session.Scope,
+		)
+	}
+	tw.Flush()
+}
+
+func sessionState(session entity.Session) string {
+	switch {
+	case session.IsRevorked:
+		return "revoked"
+	case session.ExpiresAt.Before(time.Now()):
+		return "expired"
+	default:
+		return "active"
+	}
+}
@@ -0,0 +1,59 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/BabyJhon/medods-test-task/internal/handlers"
+)
+
+// cookiePolicy reads the token cookie settings from COOKIE_* env vars.
+func cookiePolicy() (*handlers.CookiePolicy, error) {
+	cfg := handlers.CookieConfig{
+		Domain:      os.Getenv("COOKIE_DOMAIN"),
+		Path:        os.Getenv("COOKIE_PATH"),
+		RefreshPath: os.Getenv("COOKIE_REFRESH_PATH"),
+		Prefix:      os.Getenv("COOKIE_PREFIX"),
+	}
+
+	var err error
+	if cfg.SameSite, err = handlers.ParseSameSite(os.Getenv("COOKIE_SAMESITE")); err != nil {
+		return nil, fmt.Errorf("COOKIE_SAMESITE: %w", err)
+	}
+	if value := os.Getenv("COOKIE_SECURE"); value != "" {
+		secure, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("COOKIE_SECURE: %w", err)
+		}
+		cfg.Insecure = !secure
+	}
+
+	policy, err := handlers.NewCookiePolicy(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("cookie policy: %w", err)
+	}
+	return policy, nil
+}
+
+// csrfGuard reads CSRF_TRUSTED_ORIGINS, a comma separated list of frontend origins allowed
+// to call cookie-authenticated routes besides the API host itself.
+func csrfGuard() (*handlers.CSRFGuard, error) {
+	guard, err := handlers.NewCSRFGuard(envList("CSRF_TRUSTED_ORIGINS"))
+	if err != nil {
+		return nil, fmt.Errorf("CSRF_TRUSTED_ORIGINS: %w", err)
+	}
+	return guard, nil
+}
+
+// envList splits a comma separated env var, skipping empty items.
+func envList(key string) []string {
+	var items []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
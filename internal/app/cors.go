@@ -0,0 +1,60 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/BabyJhon/medods-test-task/internal/handlers"
+)
+
+// corsRoutes reads the CORS policies of the public route groups from CORS_* env vars.
+// The auth and OAuth groups serve the cookie flows, so they allow credentials and need
+// explicit origins; discovery documents are public and readable from any origin by default.
+func corsRoutes() (handlers.CORSRoutes, error) {
+	var maxAge time.Duration
+	if value := os.Getenv("CORS_MAX_AGE"); value != "" {
+		var err error
+		if maxAge, err = time.ParseDuration(value); err != nil {
+			return handlers.CORSRoutes{}, fmt.Errorf("CORS_MAX_AGE: %w", err)
+		}
+	}
+
+	var (
+		routes handlers.CORSRoutes
+		err    error
+	)
+	if routes.Auth, err = corsPolicy("CORS_ALLOWED_ORIGINS", nil, true, maxAge); err != nil {
+		return handlers.CORSRoutes{}, err
+	}
+	if routes.OAuth, err = corsPolicy("CORS_OAUTH_ALLOWED_ORIGINS", nil, true, maxAge); err != nil {
+		return handlers.CORSRoutes{}, err
+	}
+	if routes.Discovery, err = corsPolicy("CORS_DISCOVERY_ALLOWED_ORIGINS", []string{handlers.CORSAnyOrigin}, false, maxAge); err != nil {
+		return handlers.CORSRoutes{}, err
+	}
+
+	return routes, nil
+}
+
+// corsPolicy returns nil, which disables CORS for the group, when neither the env var nor
+// defaultOrigins list any origin.
+func corsPolicy(key string, defaultOrigins []string, credentials bool, maxAge time.Duration) (*handlers.CORS, error) {
+	origins := envList(key)
+	if len(origins) == 0 {
+		origins = defaultOrigins
+	}
+	if len(origins) == 0 {
+		return nil, nil
+	}
+
+	cors, err := handlers.NewCORS(handlers.CORSConfig{
+		AllowedOrigins:   origins,
+		AllowCredentials: credentials,
+		MaxAge:           maxAge,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", key, err)
+	}
+	return cors, nil
+}
@@ -0,0 +1,133 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/BabyJhon/medods-test-task/internal/service"
+)
+
+const keysUsage = "keys generate [--out <file>] [--force] | rotate [--file <file>] [--keep <n>]"
+
+// Keys manages the OIDC signing key file. Running instances read it on startup, so they have
+// to be restarted after a rotation.
+func Keys(args []string) error {
+	name, args, err := subcommand(args, keysUsage)
+	if err != nil {
+		return err
+	}
+
+	switch name {
+	case "generate":
+		return generateKeys(args)
+	case "rotate":
+		return rotateKeys(args)
+	default:
+		return errors.New("usage: " + keysUsage)
+	}
+}
+
+func generateKeys(args []string) error {
+	fs := newFlagSet("keys generate")
+	out := fs.String("out", "", "key file, the key is written to stdout if empty")
+	force := fs.Bool("force", false, "overwrite an existing key file")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	key, err := service.GenerateSigningKey()
+	if err != nil {
+		return err
+	}
+
+	data, err := service.EncodeSigningKeys([]*service.SigningKey{key})
+	if err != nil {
+		return err
+	}
+
+	if *out == "" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+
+	if _, err := os.Stat(*out); err == nil && !*force {
+		return fmt.Errorf("%s already exists, use keys rotate or --force", *out)
+	}
+	if err := writeKeyFile(*out, data); err != nil {
+		return err
+	}
+
+	fmt.Printf("generated signing key %s\n", key.ID)
+	return nil
+}
+
+func rotateKeys(args []string) error {
+	fs := newFlagSet("keys rotate")
+	file := fs.String("file", "", "key file, OIDC_SIGNING_KEY_FILE if empty")
+	keep := fs.Int("keep", 1, "number of previous keys kept in JWKS")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *keep < 0 {
+		return errors.New("--keep must not be negative")
+	}
+
+	if *file == "" {
+		if err := loadEnv(); err != nil {
+			return err
+		}
+		*file = os.Getenv("OIDC_SIGNING_KEY_FILE")
+	}
+	if *file == "" {
+		return errors.New("key file is not set, use --file or OIDC_SIGNING_KEY_FILE")
+	}
+
+	keys, err := service.LoadSigningKeys(*file)
+	if err != nil {
+		return err
+	}
+
+	keys, err = service.RotateSigningKeys(keys, *keep)
+	if err != nil {
+		return err
+	}
+
+	data, err := service.EncodeSigningKeys(keys)
+	if err != nil {
+		return err
+	}
+	if err := writeKeyFile(*file, data); err != nil {
+		return err
+	}
+
+	fmt.Printf("new signing key %s\n", keys[0].ID)
+	for _, key := range keys[1:] {
+		fmt.Printf("previous key %s\n", key.ID)
+	}
+	return nil
+}
+
+// writeKeyFile replaces path atomically, so a crash can't leave a truncated key file.
+func writeKeyFile(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".signing-key-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(0o600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
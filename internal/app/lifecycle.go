@@ -0,0 +1,65 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/BabyJhon/medods-test-task/pkg/httpserver"
+	"github.com/BabyJhon/medods-test-task/pkg/lifecycle"
+)
+
+// lifecycleConfig reads SHUTDOWN_TIMEOUT, the deadline of the whole shutdown, and
+// SHUTDOWN_DRAIN_DELAY, how long /readyz fails before the servers stop, both durations
+// like 30s.
+func lifecycleConfig() (lifecycle.Config, error) {
+	var cfg lifecycle.Config
+
+	var err error
+	if cfg.ShutdownTimeout, err = envDuration("SHUTDOWN_TIMEOUT"); err != nil {
+		return lifecycle.Config{}, err
+	}
+	if cfg.DrainDelay, err = envDuration("SHUTDOWN_DRAIN_DELAY"); err != nil {
+		return lifecycle.Config{}, err
+	}
+	if cfg.ShutdownTimeout != 0 && cfg.DrainDelay >= cfg.ShutdownTimeout {
+		return lifecycle.Config{}, errors.New("SHUTDOWN_DRAIN_DELAY must be shorter than SHUTDOWN_TIMEOUT")
+	}
+
+	return cfg, nil
+}
+
+func envDuration(key string) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return 0, nil
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration < 0 {
+		return 0, fmt.Errorf("%s: want a duration like 30s", key)
+	}
+	return duration, nil
+}
+
+// serverComponent runs the server in the background. A server that stops serving on its
+// own, for example because the port is taken, shuts the application down.
+func serverComponent(name string, lc *lifecycle.Manager, port string, handler http.Handler, cfg *httpserver.TLSConfig) lifecycle.Component {
+	srv := new(httpserver.Server)
+
+	return lifecycle.Component{
+		Name: name,
+		Start: func(context.Context) error {
+			go func() {
+				if err := runServer(srv, port, handler, cfg); !errors.Is(err, http.ErrServerClosed) {
+					lc.Fail(name, err)
+				}
+			}()
+			return nil
+		},
+		Stop: srv.ShutDown,
+	}
+}
@@ -0,0 +1,56 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/BabyJhon/medods-test-task/internal/logging"
+)
+
+// setupLogging reads LOG_LEVEL, LOG_FORMAT and the redaction policy: LOG_REDACT, true by
+// default, LOG_HASH_KEY and LOG_IPV4_PREFIX / LOG_IPV6_PREFIX.
+func setupLogging() error {
+	cfg := logging.Config{
+		Level:  os.Getenv("LOG_LEVEL"),
+		Format: os.Getenv("LOG_FORMAT"),
+		Redaction: logging.Policy{
+			HashKey: []byte(os.Getenv("LOG_HASH_KEY")),
+		},
+	}
+
+	if value := os.Getenv("LOG_REDACT"); value != "" {
+		redact, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("LOG_REDACT: %w", err)
+		}
+		cfg.Redaction.Disabled = !redact
+	}
+
+	var err error
+	if cfg.Redaction.IPv4Prefix, err = envPrefix("LOG_IPV4_PREFIX", 32); err != nil {
+		return err
+	}
+	if cfg.Redaction.IPv6Prefix, err = envPrefix("LOG_IPV6_PREFIX", 128); err != nil {
+		return err
+	}
+
+	if err := logging.Setup(cfg); err != nil {
+		return fmt.Errorf("logging: %w", err)
+	}
+	return nil
+}
+
+// envPrefix reads a prefix length of at most bits, zero if the env var is not set.
+func envPrefix(key string, bits int) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return 0, nil
+	}
+
+	prefix, err := strconv.Atoi(value)
+	if err != nil || prefix < 1 || prefix > bits {
+		return 0, fmt.Errorf("%s: want a prefix length from 1 to %d", key, bits)
+	}
+	return prefix, nil
+}
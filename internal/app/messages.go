@@ -0,0 +1,37 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/BabyJhon/medods-test-task/internal/i18n"
+)
+
+// localizer reads DEFAULT_LANGUAGE, the language for clients that accept none of the
+// supported ones, English by default.
+func localizer() (*i18n.Localizer, error) {
+	fallback := os.Getenv("DEFAULT_LANGUAGE")
+	if fallback == "" {
+		fallback = i18n.English
+	}
+
+	messages, err := i18n.New(fallback)
+	if err != nil {
+		return nil, fmt.Errorf("DEFAULT_LANGUAGE: %w", err)
+	}
+	return messages, nil
+}
+
+// webhookTranslator reads WEBHOOK_LANGUAGE, the language of webhook event descriptions.
+// Webhooks have no Accept-Language, so it defaults to DEFAULT_LANGUAGE.
+func webhookTranslator(messages *i18n.Localizer) (i18n.Translator, error) {
+	lang := os.Getenv("WEBHOOK_LANGUAGE")
+	if lang == "" {
+		return messages.Translator(""), nil
+	}
+	if !slices.Contains(messages.Languages(), lang) {
+		return i18n.Translator{}, fmt.Errorf("WEBHOOK_LANGUAGE: unsupported language %q, want one of %v", lang, messages.Languages())
+	}
+	return messages.Translator(lang), nil
+}
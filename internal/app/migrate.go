@@ -0,0 +1,86 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pressly/goose/v3"
+)
+
+const migrateUsage = "usage: migrate up|down|status|redo"
+
+// Migrate runs the migrate subcommand against the storage backend from the env.
+func Migrate(args []string) error {
+	if len(args) != 1 {
+		return errors.New(migrateUsage)
+	}
+
+	if err := loadEnv(); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	store, err := openStorage(ctx)
+	if err != nil {
+		return err
+	}
+	defer store.close()
+
+	if store.migrator == nil {
+		return errors.New("storage backend has no migrations")
+	}
+
+	switch args[0] {
+	case "up":
+		results, err := store.migrator.Up(ctx)
+		printResults(os.Stdout, results...)
+		return err
+	case "down":
+		result, err := store.migrator.Down(ctx)
+		if err != nil {
+			return err
+		}
+		printResults(os.Stdout, result)
+	case "redo":
+		results, err := store.migrator.Redo(ctx)
+		printResults(os.Stdout, results...)
+		return err
+	case "status":
+		statuses, err := store.migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		printStatus(os.Stdout, statuses)
+	default:
+		return errors.New(migrateUsage)
+	}
+
+	return nil
+}
+
+func printResults(w io.Writer, results ...*goose.MigrationResult) {
+	if len(results) == 0 {
+		fmt.Fprintln(w, "no migrations to apply")
+	}
+	for _, result := range results {
+		fmt.Fprintln(w, result)
+	}
+}
+
+func printStatus(w io.Writer, statuses []*goose.MigrationStatus) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "VERSION\tSTATE\tAPPLIED AT\tMIGRATION")
+	for _, status := range statuses {
+		appliedAt := "-"
+		if !status.AppliedAt.IsZero() {
+			appliedAt = status.AppliedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\n", status.Source.Version, status.State, appliedAt, status.Source.Path)
+	}
+	tw.Flush()
+}
@@ -0,0 +1,94 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/BabyJhon/medods-test-task/internal/i18n"
+	"github.com/BabyJhon/medods-test-task/internal/service"
+	"github.com/gofrs/uuid"
+)
+
+const sessionsUsage = "sessions list --user <guid> | revoke --user <guid> | revoke --id <session id> | purge --expired"
+
+// Sessions runs the sessions subcommand against the storage backend from the env.
+func Sessions(args []string) error {
+	name, args, err := subcommand(args, sessionsUsage)
+	if err != nil {
+		return err
+	}
+
+	fs := newFlagSet("sessions " + name)
+	userID := fs.String("user", "", "user GUID")
+	sessionID := fs.String("id", "", "session ID")
+	expired := fs.Bool("expired", false, "delete sessions with expired refresh tokens")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	valid := (name == "list" && *userID != "") ||
+		(name == "revoke" && (*userID == "") != (*sessionID == "")) ||
+		(name == "purge" && *expired)
+	if !valid {
+		return errors.New("usage: " + sessionsUsage)
+	}
+
+	if err := loadEnv(); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	store, err := openStorage(ctx)
+	if err != nil {
+		return err
+	}
+	defer store.close()
+
+	sessions := service.NewSessionService(store.repos.Auth, store.repos.Events)
+
+	switch {
+	case name == "list":
+		guid, err := uuid.FromString(*userID)
+		if err != nil {
+			return fmt.Errorf("invalid --user: %w", err)
+		}
+
+		list, err := sessions.ListUserSessions(ctx, guid)
+		if err != nil {
+			return err
+		}
+		printSessions(os.Stdout, list)
+	case name == "revoke" && *userID != "":
+		guid, err := uuid.FromString(*userID)
+		if err != nil {
+			return fmt.Errorf("invalid --user: %w", err)
+		}
+
+		count, err := sessions.LogoutUser(ctx, guid)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("revoked %d sessions\n", count)
+	case name == "revoke":
+		id, err := uuid.FromString(*sessionID)
+		if err != nil {
+			return fmt.Errorf("invalid --id: %w", err)
+		}
+
+		auth := service.NewAuthService(store.repos.Auth, store.repos.RBAC, store.repos.Events, nil, nil, i18n.Translator{})
+		if err := auth.RevokeToken(ctx, id); err != nil {
+			return err
+		}
+		fmt.Println("revoked 1 session")
+	case name == "purge":
+		count, err := sessions.PurgeExpiredSessions(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("deleted %d expired sessions\n", count)
+	}
+
+	return nil
+}
@@ -0,0 +1,116 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/BabyJhon/medods-test-task/internal/migrate"
+	"github.com/BabyJhon/medods-test-task/internal/repo"
+	"github.com/BabyJhon/medods-test-task/internal/repo/memory"
+	"github.com/BabyJhon/medods-test-task/internal/repo/sqlite"
+	"github.com/BabyJhon/medods-test-task/pkg/postgres"
+	"github.com/sirupsen/logrus"
+)
+
+type storage struct {
+	repos *repo.Repository
+	// migrator is nil for backends without a schema.
+	migrator *migrate.Migrator
+	// autoMigrate reports whether migrations are applied on startup.
+	autoMigrate bool
+	close       func()
+}
+
+// openStorage connects to the backend selected by STORAGE_BACKEND.
+func openStorage(ctx context.Context) (*storage, error) {
+	switch backend := os.Getenv("STORAGE_BACKEND"); backend {
+	case "", "postgres":
+		pool, err := postgres.NewPG(ctx, postgres.Config{
+			Host:     os.Getenv("PG_HOST"),
+			Port:     os.Getenv("PG_PORT"),
+			Username: os.Getenv("PG_USER"),
+			Password: os.Getenv("DB_PASSWORD"),
+			DBName:   os.Getenv("PG_DATABASE_NAME"),
+			SSLMode:  os.Getenv("PG_SSLMODE"),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed init db: %w", err)
+		}
+
+		migrator, err := migrate.NewPostgres(pool)
+		if err != nil {
+			pool.Close()
+			return nil, fmt.Errorf("failed init migrations: %w", err)
+		}
+
+		autoMigrate, err := envBool("AUTO_MIGRATE", false)
+		if err != nil {
+			migrator.Close()
+			pool.Close()
+			return nil, err
+		}
+
+		return &storage{
+			repos:       repo.NewRepository(pool),
+			migrator:    migrator,
+			autoMigrate: autoMigrate,
+			close: func() {
+				migrator.Close()
+				pool.Close()
+			},
+		}, nil
+	case "sqlite":
+		db, err := sqlite.NewSQLite(ctx, sqlite.Config{
+			Path: os.Getenv("SQLITE_PATH"),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed init db: %w", err)
+		}
+
+		migrator, err := migrate.NewSQLite(db)
+		if err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed init migrations: %w", err)
+		}
+
+		// A single node has nobody else to run migrations, so they are applied by default.
+		autoMigrate, err := envBool("AUTO_MIGRATE", true)
+		if err != nil {
+			db.Close()
+			return nil, err
+		}
+
+		return &storage{
+			repos:       sqlite.NewRepository(db),
+			migrator:    migrator,
+			autoMigrate: autoMigrate,
+			close: func() {
+				db.Close()
+			},
+		}, nil
+	case "memory":
+		logrus.Warn("using in-memory storage, all data is lost on restart")
+
+		return &storage{
+			repos: memory.NewRepository(),
+			close: func() {},
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", backend)
+	}
+}
+
+func envBool(key string, fallback bool) (bool, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return parsed, nil
+}
@@ -0,0 +1,49 @@
+package app
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/BabyJhon/medods-test-task/pkg/httpserver"
+)
+
+// tlsConfig reads the TLS settings of a listener from env vars with the given prefix
+// ("" for the public API, "ADMIN_" for the admin API). It returns nil when no certificate
+// is configured and the listener serves plain HTTP.
+func tlsConfig(prefix string) (*httpserver.TLSConfig, error) {
+	cfg := httpserver.TLSConfig{
+		CertFile:     os.Getenv(prefix + "TLS_CERT_FILE"),
+		KeyFile:      os.Getenv(prefix + "TLS_KEY_FILE"),
+		ClientCAFile: os.Getenv(prefix + "TLS_CLIENT_CA_FILE"),
+	}
+	if cfg.CertFile == "" && cfg.KeyFile == "" {
+		return nil, nil
+	}
+
+	var err error
+	if cfg.ClientAuth, err = httpserver.ParseClientAuth(os.Getenv(prefix + "TLS_CLIENT_AUTH")); err != nil {
+		return nil, fmt.Errorf("%sTLS_CLIENT_AUTH: %w", prefix, err)
+	}
+	if cfg.MinVersion, err = httpserver.ParseTLSVersion(os.Getenv(prefix + "TLS_MIN_VERSION")); err != nil {
+		return nil, fmt.Errorf("%sTLS_MIN_VERSION: %w", prefix, err)
+	}
+	if cfg.CipherSuites, err = httpserver.ParseCipherSuites(os.Getenv(prefix + "TLS_CIPHER_SUITES")); err != nil {
+		return nil, fmt.Errorf("%sTLS_CIPHER_SUITES: %w", prefix, err)
+	}
+	if value := os.Getenv(prefix + "TLS_RELOAD_INTERVAL"); value != "" {
+		if cfg.ReloadInterval, err = time.ParseDuration(value); err != nil {
+			return nil, fmt.Errorf("%sTLS_RELOAD_INTERVAL: %w", prefix, err)
+		}
+	}
+
+	return &cfg, nil
+}
+
+func runServer(srv *httpserver.Server, port string, handler http.Handler, cfg *httpserver.TLSConfig) error {
+	if cfg == nil {
+		return srv.Run(port, handler)
+	}
+	return srv.RunTLS(port, handler, *cfg)
+}
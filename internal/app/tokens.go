@@ -0,0 +1,220 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/BabyJhon/medods-test-task/internal/i18n"
+	"github.com/BabyJhon/medods-test-task/internal/service"
+	"github.com/gofrs/uuid"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const tokensUsage = "tokens inspect <jwt> | admin --user <guid>"
+
+type tokenInspection struct {
+	Type    string         `json:"type"`
+	Header  map[string]any `json:"header"`
+	Claims  jwt.MapClaims  `json:"claims"`
+	Valid   bool           `json:"valid"`
+	Error   string         `json:"error,omitempty"`
+	Session *sessionInfo   `json:"session,omitempty"`
+}
+
+type sessionInfo struct {
+	ID        string `json:"id"`
+	UserID    string `json:"user_id"`
+	State     string `json:"state"`
+	CreatedAt string `json:"created_at"`
+	ExpiresAt string `json:"expires_at"`
+	IP        string `json:"ip"`
+	UserAgent string `json:"user_agent"`
+	Scope     string `json:"scope"`
+}
+
+// Tokens runs the tokens subcommand. inspect decodes a token, verifies it with the keys from
+// the env and, for access tokens, shows the state of their session. admin issues an access
+// token with the admin scope, which the public endpoints never grant.
+func Tokens(args []string) error {
+	name, args, err := subcommand(args, tokensUsage)
+	if err != nil {
+		return err
+	}
+	switch {
+	case name == "inspect" && len(args) == 1:
+		return inspectToken(args[0])
+	case name == "admin":
+		return issueAdminTokens(args)
+	default:
+		return errors.New("usage: " + tokensUsage)
+	}
+}
+
+func inspectToken(rawToken string) error {
+	if err := loadEnv(); err != nil {
+		return err
+	}
+
+	claims := jwt.MapClaims{}
+	token, _, err := jwt.NewParser().ParseUnverified(rawToken, claims)
+	if err != nil {
+		return fmt.Errorf("malformed token: %w", err)
+	}
+
+	inspection := tokenInspection{
+		Header: token.Header,
+		Claims: claims,
+	}
+
+	// Access и ID токены подписываются одним ключом и различаются по typ. HS512 access токены
+	// выдавались до перехода на RS256 и проверяются ключом SIGNING_KEY.
+	typ, _ := token.Header["typ"].(string)
+	_, legacy := token.Method.(*jwt.SigningMethodHMAC)
+	_, rsa := token.Method.(*jwt.SigningMethodRSA)
+	switch {
+	case legacy || (rsa && strings.EqualFold(typ, service.AccessTokenType)):
+		inspection.Type = "access_token"
+		var keys []*service.SigningKey
+		if !legacy {
+			if keys, err = verificationKeys(); err != nil {
+				return err
+			}
+		}
+		if err := inspectAccessToken(&inspection, rawToken, keys); err != nil {
+			return err
+		}
+	case rsa:
+		inspection.Type = "id_token"
+		if err := inspectIDToken(&inspection, rawToken); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unsupported signing method %s", token.Method.Alg())
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(inspection)
+}
+
+func issueAdminTokens(args []string) error {
+	fs := newFlagSet("tokens admin")
+	userID := fs.String("user", "", "user GUID")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *userID == "" || fs.NArg() != 0 {
+		return errors.New("usage: " + tokensUsage)
+	}
+	guid, err := uuid.FromString(*userID)
+	if err != nil {
+		return fmt.Errorf("invalid --user: %w", err)
+	}
+
+	if err := loadEnv(); err != nil {
+		return err
+	}
+	// The token has to verify in the running service, so an ephemeral key won't do.
+	keys, err := verificationKeys()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	store, err := openStorage(ctx)
+	if err != nil {
+		return err
+	}
+	defer store.close()
+
+	auth := service.NewAuthService(store.repos.Auth, store.repos.RBAC, store.repos.Events, nil, keys, i18n.Translator{})
+	tokens, err := auth.CreateAdminTokens(ctx, guid, "cli")
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(tokens)
+}
+
+func inspectAccessToken(inspection *tokenInspection, rawToken string, keys []*service.SigningKey) error {
+	auth := service.NewAuthService(nil, nil, nil, nil, keys, i18n.Translator{})
+
+	_, err := auth.Parsetoken(rawToken)
+	inspection.Valid = err == nil
+	if err != nil {
+		inspection.Error = err.Error()
+	}
+
+	// An expired token with a valid signature still points to a session worth looking at.
+	claims, err := auth.ParseExpiredToken(rawToken)
+	if err != nil {
+		return nil
+	}
+	// client_credentials tokens have no session behind them.
+	if claims.SessionID == uuid.Nil {
+		return nil
+	}
+
+	ctx := context.Background()
+	store, err := openStorage(ctx)
+	if err != nil {
+		return err
+	}
+	defer store.close()
+
+	session, err := store.repos.GetSessionByID(ctx, claims.SessionID)
+	if err != nil {
+		inspection.Valid = false
+		inspection.Error = err.Error()
+		return nil
+	}
+
+	inspection.Session = &sessionInfo{
+		ID:        session.ID.String(),
+		UserID:    session.UserId.String(),
+		State:     sessionState(session),
+		CreatedAt: session.CreatedAt.Format(time.RFC3339),
+		ExpiresAt: session.ExpiresAt.Format(time.RFC3339),
+		IP:        session.IP.String(),
+		UserAgent: session.UserAgent,
+		Scope:     session.Scope,
+	}
+	if inspection.Valid && session.IsRevorked {
+		inspection.Valid = false
+		inspection.Error = "token is revoked"
+	}
+
+	return nil
+}
+
+func inspectIDToken(inspection *tokenInspection, rawToken string) error {
+	keys, err := verificationKeys()
+	if err != nil {
+		return err
+	}
+
+	_, err = service.NewOIDCService(nil, keys).ParseIDToken(rawToken)
+	inspection.Valid = err == nil
+	if err != nil {
+		inspection.Error = err.Error()
+	}
+
+	return nil
+}
+
+// verificationKeys reads the keys the service signs tokens with. Without a key file the
+// service uses an ephemeral key that inspect can't know.
+func verificationKeys() ([]*service.SigningKey, error) {
+	path := os.Getenv("OIDC_SIGNING_KEY_FILE")
+	if path == "" {
+		return nil, errors.New("OIDC_SIGNING_KEY_FILE is not set, RS256 tokens can't be verified")
+	}
+	return service.LoadSigningKeys(path)
+}
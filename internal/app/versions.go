@@ -0,0 +1,36 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/BabyJhon/medods-test-task/internal/handlers"
+)
+
+// apiV1Released is when /api/v1 appeared and the unversioned paths became deprecated.
+var apiV1Released = time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)
+
+// legacyAPI reads LEGACY_API_DEPRECATED_AT and LEGACY_API_SUNSET, dates like 2027-04-19.
+// Without LEGACY_API_SUNSET the unversioned paths get no Sunset header.
+func legacyAPI() (handlers.LegacyAPI, error) {
+	legacy := handlers.LegacyAPI{DeprecatedAt: apiV1Released}
+
+	var err error
+	if value := os.Getenv("LEGACY_API_DEPRECATED_AT"); value != "" {
+		if legacy.DeprecatedAt, err = time.Parse(time.DateOnly, value); err != nil {
+			return handlers.LegacyAPI{}, fmt.Errorf("LEGACY_API_DEPRECATED_AT: %w", err)
+		}
+	}
+	if value := os.Getenv("LEGACY_API_SUNSET"); value != "" {
+		if legacy.Sunset, err = time.Parse(time.DateOnly, value); err != nil {
+			return handlers.LegacyAPI{}, fmt.Errorf("LEGACY_API_SUNSET: %w", err)
+		}
+		if !legacy.Sunset.After(legacy.DeprecatedAt) {
+			return handlers.LegacyAPI{}, errors.New("LEGACY_API_SUNSET must be after LEGACY_API_DEPRECATED_AT")
+		}
+	}
+
+	return legacy, nil
+}
@@ -11,5 +11,26 @@ type Claimes struct {
 	SessionID uuid.UUID `json:"session_id"`
 	UserAgent string    `json:"user_agent"`
 	IP        net.IP    `json:"ip"`
+	ClientID  string    `json:"client_id,omitempty"`
+	Scope     string    `json:"scope,omitempty"`
+	Roles     []string  `json:"roles,omitempty"`
+	// Confirmation is set when the token is bound to the client's TLS certificate or
+	// DPoP key.
+	Confirmation *Confirmation `json:"cnf,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// Confirmation is the cnf claim (RFC 7800) binding a token to a key the client holds.
+type Confirmation struct {
+	// X5tS256 is the SHA-256 thumbprint of the client certificate (RFC 8705).
+	X5tS256 string `json:"x5t#S256,omitempty"`
+	// JKT is the JWK SHA-256 thumbprint of the client's DPoP key (RFC 9449).
+	JKT string `json:"jkt,omitempty"`
+}
+
+type IDTokenClaims struct {
+	Nonce    string           `json:"nonce,omitempty"`
+	AuthTime *jwt.NumericDate `json:"auth_time,omitempty"`
+	AMR      []string         `json:"amr,omitempty"`
 	jwt.RegisteredClaims
 }
@@ -0,0 +1,19 @@
+package entity
+
+import (
+	"net"
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+type SecurityEvent struct {
+	ID        uuid.UUID     `json:"id" db:"id" swaggertype:"string"`
+	UserID    uuid.UUID     `json:"user_id" db:"user_id" swaggertype:"string"`
+	SessionID uuid.NullUUID `json:"session_id" db:"session_id" swaggertype:"string"`
+	Type      string        `json:"type" db:"type"`
+	IP        net.IP        `json:"ip,omitempty" db:"ip" swaggertype:"string"`
+	UserAgent string        `json:"user_agent,omitempty" db:"user_agent"`
+	Details   string        `json:"details,omitempty" db:"details"`
+	CreatedAt time.Time     `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,106 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+type AuthorizationCode struct {
+	CodeHash            string    `db:"code_hash"`
+	ClientID            string    `db:"client_id"`
+	UserID              uuid.UUID `db:"user_id"`
+	RedirectURI         string    `db:"redirect_uri"`
+	CodeChallenge       string    `db:"code_challenge"`
+	CodeChallengeMethod string    `db:"code_challenge_method"`
+	Scope               string    `db:"scope"`
+	Nonce               string    `db:"nonce"`
+	AuthTime            time.Time `db:"auth_time"`
+	CreatedAt           time.Time `db:"created_at"`
+	ExpiresAt           time.Time `db:"expires_at"`
+	IsUsed              bool      `db:"is_used"`
+}
+
+type OAuthClient struct {
+	ClientID              string    `json:"client_id" db:"client_id"`
+	SecretHash            string    `json:"-" db:"secret_hash"`
+	Name                  string    `json:"name" db:"name"`
+	GrantTypes            []string  `json:"grant_types" db:"grant_types"`
+	Scopes                []string  `json:"scopes" db:"scopes"`
+	RedirectURIs          []string  `json:"redirect_uris" db:"redirect_uris"`
+	IsPublic              bool      `json:"is_public" db:"is_public"`
+	IsDisabled            bool      `json:"is_disabled" db:"is_disabled"`
+	DPoPBoundAccessTokens bool      `json:"dpop_bound_access_tokens" db:"dpop_bound_access_tokens"`
+	CreatedAt             time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt             time.Time `json:"updated_at" db:"updated_at"`
+}
+
+type AuthorizeRequest struct {
+	ResponseType        string
+	ClientID            string
+	RedirectURI         string
+	State               string
+	CodeChallenge       string
+	CodeChallengeMethod string
+	Scope               string
+	Nonce               string
+	UserID              uuid.UUID
+}
+
+type TokenRequest struct {
+	GrantType    string
+	Code         string
+	RedirectURI  string
+	ClientID     string
+	ClientSecret string
+	CodeVerifier string
+	Scope        string
+	// Confirmation binds the issued tokens to the client certificate of the request and
+	// the key of its DPoP proof.
+	Confirmation *Confirmation
+}
+
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	Scope        string `json:"scope,omitempty"`
+	IDToken      string `json:"id_token,omitempty"`
+}
+
+type OpenIDConfiguration struct {
+	Issuer                            string   `json:"issuer"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	UserInfoEndpoint                  string   `json:"userinfo_endpoint"`
+	IntrospectionEndpoint             string   `json:"introspection_endpoint"`
+	JWKSURI                           string   `json:"jwks_uri"`
+	ScopesSupported                   []string `json:"scopes_supported"`
+	ResponseTypesSupported            []string `json:"response_types_supported"`
+	GrantTypesSupported               []string `json:"grant_types_supported"`
+	SubjectTypesSupported             []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported"`
+	ClaimsSupported                   []string `json:"claims_supported"`
+	CertificateBoundAccessTokens      bool     `json:"tls_client_certificate_bound_access_tokens"`
+	DPoPSigningAlgValuesSupported     []string `json:"dpop_signing_alg_values_supported"`
+}
+
+type IntrospectionResponse struct {
+	Active       bool          `json:"active"`
+	Scope        string        `json:"scope,omitempty"`
+	ClientID     string        `json:"client_id,omitempty"`
+	Sub          string        `json:"sub,omitempty"`
+	TokenType    string        `json:"token_type,omitempty"`
+	Exp          int64         `json:"exp,omitempty"`
+	Iat          int64         `json:"iat,omitempty"`
+	SessionID    string        `json:"sid,omitempty"`
+	Roles        []string      `json:"roles,omitempty"`
+	Confirmation *Confirmation `json:"cnf,omitempty"`
+}
+
+type UserInfo struct {
+	Sub string `json:"sub"`
+}
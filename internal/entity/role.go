@@ -0,0 +1,9 @@
+package entity
+
+import "time"
+
+type Role struct {
+	Name        string    `json:"name" db:"name"`
+	Permissions []string  `json:"permissions" db:"permissions"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
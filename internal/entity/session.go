@@ -16,4 +16,27 @@ type Session struct {
 	CreatedAt   time.Time `db:"created_at"`
 	ExpiresAt   time.Time `db:"expires_at"`
 	IsRevorked  bool      `db:"is_revorked"`
+	Scope       string    `json:"scope" db:"scope"`
+}
+
+// SessionFilter selects sessions for search. Zero fields don't filter.
+type SessionFilter struct {
+	UserID uuid.UUID
+	IP     net.IP
+	// UserAgent matches a case-insensitive substring.
+	UserAgent   string
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+	// ActiveOnly skips revoked and expired sessions.
+	ActiveOnly bool
+	Limit      int
+}
+
+// UserSummary aggregates the sessions of one user. Users are not stored separately, so
+// the list of users is the list of users that have sessions.
+type UserSummary struct {
+	UserID         uuid.UUID `json:"user_id" swaggertype:"string"`
+	Sessions       int       `json:"sessions"`
+	ActiveSessions int       `json:"active_sessions"`
+	LastLoginAt    time.Time `json:"last_login_at"`
 }
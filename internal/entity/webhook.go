@@ -0,0 +1,17 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+type WebhookDelivery struct {
+	ID         uuid.UUID `json:"id" db:"id" swaggertype:"string"`
+	Event      string    `json:"event" db:"event"`
+	Message    string    `json:"message" db:"message"`
+	URL        string    `json:"url" db:"url"`
+	StatusCode int       `json:"status_code,omitempty" db:"status_code"`
+	Error      string    `json:"error,omitempty" db:"error"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+}
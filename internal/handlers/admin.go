@@ -0,0 +1,287 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/BabyJhon/medods-test-task/internal/entity"
+	"github.com/gin-gonic/gin"
+	"github.com/gofrs/uuid"
+)
+
+type createClientInput struct {
+	Name                  string   `json:"name" binding:"required"`
+	GrantTypes            []string `json:"grant_types" binding:"required"`
+	Scopes                []string `json:"scopes"`
+	RedirectURIs          []string `json:"redirect_uris"`
+	IsPublic              bool     `json:"is_public"`
+	DPoPBoundAccessTokens bool     `json:"dpop_bound_access_tokens"`
+}
+
+type clientResponse struct {
+	entity.OAuthClient
+	ClientSecret string `json:"client_secret,omitempty"`
+}
+
+type clientSecretResponse struct {
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+}
+
+// CreateClient godoc
+// @Summary Регистрация OAuth клиента
+// @Description Создает OAuth клиента. Секрет конфиденциального клиента возвращается только один раз, в базе хранится его bcrypt хэш.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param Authorization header string true "Токен администратора в формате: Bearer <token>"
+// @Param input body createClientInput true "Параметры клиента"
+// @Success 201 {object} clientResponse "Клиент создан"
+// @Failure 400 {object} Problem "Неверный запрос"
+// @Failure 401 {object} Problem "Неавторизованный доступ"
+// @Failure 403 {object} Problem "Нет роли admin или scope admin"
+// @Failure 500 {object} Problem "Внутренняя ошибка сервера"
+// @Router /admin/clients [post]
+func (h *Handler) createClient(c *gin.Context) {
+	var input createClientInput
+	if err := c.BindJSON(&input); err != nil {
+		newErrorResponse(c, fmt.Errorf("%w: %w", errInvalidRequest, err))
+		return
+	}
+
+	client, secret, err := h.services.CreateClient(c, entity.OAuthClient{
+		Name:                  input.Name,
+		GrantTypes:            input.GrantTypes,
+		Scopes:                input.Scopes,
+		RedirectURIs:          input.RedirectURIs,
+		IsPublic:              input.IsPublic,
+		DPoPBoundAccessTokens: input.DPoPBoundAccessTokens,
+	})
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, clientResponse{
+		OAuthClient:  client,
+		ClientSecret: secret,
+	})
+}
+
+// RotateClientSecret godoc
+// @Summary Ротация секрета OAuth клиента
+// @Description Генерирует новый секрет клиента, старый секрет сразу перестает действовать.
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Param Authorization header string true "Токен администратора в формате: Bearer <token>"
+// @Param id path string true "Идентификатор клиента"
+// @Success 200 {object} clientSecretResponse "Новый секрет клиента"
+// @Failure 400 {object} Problem "Неверный запрос"
+// @Failure 401 {object} Problem "Неавторизованный доступ"
+// @Failure 403 {object} Problem "Нет роли admin или scope admin"
+// @Failure 404 {object} Problem "Клиент не найден (not_found)"
+// @Failure 500 {object} Problem "Внутренняя ошибка сервера"
+// @Router /admin/clients/{id}/secret [post]
+func (h *Handler) rotateClientSecret(c *gin.Context) {
+	clientID := c.Param("id")
+
+	secret, err := h.services.RotateClientSecret(c, clientID)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, clientSecretResponse{
+		ClientID:     clientID,
+		ClientSecret: secret,
+	})
+}
+
+// DisableClient godoc
+// @Summary Отключение OAuth клиента
+// @Description Отключает клиента: он больше не может проходить авторизацию и получать токены.
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Param Authorization header string true "Токен администратора в формате: Bearer <token>"
+// @Param id path string true "Идентификатор клиента"
+// @Success 200 {string} string "Пустой ответ при успешном отключении"
+// @Failure 401 {object} Problem "Неавторизованный доступ"
+// @Failure 403 {object} Problem "Нет роли admin или scope admin"
+// @Failure 404 {object} Problem "Клиент не найден (not_found)"
+// @Failure 500 {object} Problem "Внутренняя ошибка сервера"
+// @Router /admin/clients/{id}/disable [post]
+func (h *Handler) disableClient(c *gin.Context) {
+	if err := h.services.DisableClient(c, c.Param("id")); err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, "")
+}
+
+type upsertRoleInput struct {
+	Permissions []string `json:"permissions"`
+}
+
+type assignRoleInput struct {
+	Role string `json:"role" binding:"required"`
+}
+
+// ListRoles godoc
+// @Summary Список ролей
+// @Description Возвращает все роли с их разрешениями. Разрешения роли определяют scope, которые могут быть выданы пользователю.
+// @Tags admin
+// @Security ApiKeyAuth
+// @Produce json
+// @Param Authorization header string true "Токен администратора в формате: Bearer <token>"
+// @Success 200 {array} entity.Role "Роли"
+// @Failure 401 {object} Problem "Неавторизованный доступ"
+// @Failure 403 {object} Problem "Нет роли admin или scope admin"
+// @Failure 500 {object} Problem "Внутренняя ошибка сервера"
+// @Router /admin/roles [get]
+func (h *Handler) listRoles(c *gin.Context) {
+	roles, err := h.services.ListRoles(c)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, roles)
+}
+
+// UpsertRole godoc
+// @Summary Создание или изменение роли
+// @Description Создает роль или заменяет список ее разрешений.
+// @Tags admin
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Токен администратора в формате: Bearer <token>"
+// @Param name path string true "Название роли"
+// @Param input body upsertRoleInput true "Разрешения роли"
+// @Success 200 {string} string "Пустой ответ при успешном сохранении"
+// @Failure 400 {object} Problem "Неверный запрос"
+// @Failure 401 {object} Problem "Неавторизованный доступ"
+// @Failure 403 {object} Problem "Нет роли admin или scope admin"
+// @Failure 500 {object} Problem "Внутренняя ошибка сервера"
+// @Router /admin/roles/{name} [put]
+func (h *Handler) upsertRole(c *gin.Context) {
+	var input upsertRoleInput
+	if err := c.BindJSON(&input); err != nil {
+		newErrorResponse(c, fmt.Errorf("%w: %w", errInvalidRequest, err))
+		return
+	}
+
+	err := h.services.UpsertRole(c, entity.Role{
+		Name:        c.Param("name"),
+		Permissions: input.Permissions,
+	})
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, "")
+}
+
+// GetUserRoles godoc
+// @Summary Роли пользователя
+// @Description Возвращает роли, назначенные пользователю.
+// @Tags admin
+// @Security ApiKeyAuth
+// @Produce json
+// @Param Authorization header string true "Токен администратора в формате: Bearer <token>"
+// @Param id path string true "GUID пользователя"
+// @Success 200 {array} entity.Role "Роли пользователя"
+// @Failure 400 {object} Problem "Неверный GUID"
+// @Failure 401 {object} Problem "Неавторизованный доступ"
+// @Failure 403 {object} Problem "Нет роли admin или scope admin"
+// @Failure 500 {object} Problem "Внутренняя ошибка сервера"
+// @Router /admin/users/{id}/roles [get]
+func (h *Handler) getUserRoles(c *gin.Context) {
+	userID, err := uuid.FromString(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, invalidParam("id", err))
+		return
+	}
+
+	roles, err := h.services.GetUserRoles(c, userID)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, roles)
+}
+
+// AssignRole godoc
+// @Summary Назначение роли пользователю
+// @Description Назначает пользователю существующую роль. Новые scope попадут в токены после следующего входа или обновления токенов.
+// @Tags admin
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Токен администратора в формате: Bearer <token>"
+// @Param id path string true "GUID пользователя"
+// @Param input body assignRoleInput true "Роль"
+// @Success 200 {string} string "Пустой ответ при успешном назначении"
+// @Failure 400 {object} Problem "Неверный запрос"
+// @Failure 401 {object} Problem "Неавторизованный доступ"
+// @Failure 403 {object} Problem "Нет роли admin или scope admin"
+// @Failure 404 {object} Problem "Роль не найдена или уже назначена (not_found)"
+// @Failure 500 {object} Problem "Внутренняя ошибка сервера"
+// @Router /admin/users/{id}/roles [post]
+func (h *Handler) assignRole(c *gin.Context) {
+	userID, err := uuid.FromString(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, invalidParam("id", err))
+		return
+	}
+
+	var input assignRoleInput
+	if err := c.BindJSON(&input); err != nil {
+		newErrorResponse(c, fmt.Errorf("%w: %w", errInvalidRequest, err))
+		return
+	}
+
+	if err := h.services.AssignRole(c, userID, input.Role); err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, "")
+}
+
+// RemoveRole godoc
+// @Summary Снятие роли с пользователя
+// @Description Снимает роль с пользователя. Уже выданные access токены продолжают действовать до истечения срока.
+// @Tags admin
+// @Security ApiKeyAuth
+// @Produce json
+// @Param Authorization header string true "Токен администратора в формате: Bearer <token>"
+// @Param id path string true "GUID пользователя"
+// @Param role path string true "Название роли"
+// @Success 200 {string} string "Пустой ответ при успешном снятии"
+// @Failure 400 {object} Problem "Неверный GUID"
+// @Failure 401 {object} Problem "Неавторизованный доступ"
+// @Failure 403 {object} Problem "Нет роли admin или scope admin"
+// @Failure 404 {object} Problem "Роль не назначена пользователю (not_found)"
+// @Failure 500 {object} Problem "Внутренняя ошибка сервера"
+// @Router /admin/users/{id}/roles/{role} [delete]
+func (h *Handler) removeRole(c *gin.Context) {
+	userID, err := uuid.FromString(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, invalidParam("id", err))
+		return
+	}
+
+	if err := h.services.RemoveRole(c, userID, c.Param("role")); err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, "")
+}
@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/BabyJhon/medods-test-task/internal/entity"
+	"github.com/gin-gonic/gin"
+	"github.com/gofrs/uuid"
+)
+
+type sessionResponse struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	IP        string    `json:"ip"`
+	UserAgent string    `json:"user_agent"`
+	Scope     string    `json:"scope"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+	IsRevoked bool      `json:"is_revoked"`
+}
+
+type revokeSessionsInput struct {
+	SessionIDs []string `json:"session_ids" binding:"required"`
+}
+
+type revokedResponse struct {
+	Revoked int64 `json:"revoked"`
+}
+
+// SearchSessions godoc
+// @Summary Поиск сессий
+// @Description Ищет сессии по пользователю, IP, User-Agent и времени создания. Все параметры необязательные, результаты отсортированы от новых к старым.
+// @Tags admin
+// @Security ApiKeyAuth
+// @Produce json
+// @Param Authorization header string true "Токен администратора в формате: Bearer <token>"
+// @Param user_id    query string false "GUID пользователя"
+// @Param ip         query string false "IP адрес, точное совпадение"
+// @Param user_agent query string false "Подстрока User-Agent без учета регистра"
+// @Param from       query string false "Созданы не раньше, RFC 3339"
+// @Param to         query string false "Созданы раньше, RFC 3339"
+// @Param active     query bool   false "Только не отозванные и не истекшие"
+// @Param limit      query int    false "Максимум сессий, по умолчанию 100, не больше 1000"
+// @Success 200 {array} sessionResponse "Сессии"
+// @Failure 400 {object} Problem "Неверный параметр"
+// @Failure 401 {object} Problem "Неавторизованный доступ"
+// @Failure 403 {object} Problem "Нет роли admin или scope admin"
+// @Failure 500 {object} Problem "Внутренняя ошибка сервера"
+// @Router /admin/sessions [get]
+func (h *Handler) searchSessions(c *gin.Context) {
+	filter, err := parseSessionFilter(c)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	sessions, err := h.services.SearchSessions(c, filter)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	response := make([]sessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		response = append(response, newSessionResponse(session))
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// RevokeSessions godoc
+// @Summary Массовый отзыв сессий
+// @Description Отзывает перечисленные сессии. Уже отозванные и несуществующие сессии пропускаются.
+// @Tags admin
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Токен администратора в формате: Bearer <token>"
+// @Param input body revokeSessionsInput true "Идентификаторы сессий, не больше 1000"
+// @Success 200 {object} revokedResponse "Количество отозванных сессий"
+// @Failure 400 {object} Problem "Неверный запрос"
+// @Failure 401 {object} Problem "Неавторизованный доступ"
+// @Failure 403 {object} Problem "Нет роли admin или scope admin"
+// @Failure 500 {object} Problem "Внутренняя ошибка сервера"
+// @Router /admin/sessions/revoke [post]
+func (h *Handler) revokeSessions(c *gin.Context) {
+	var input revokeSessionsInput
+	if err := c.BindJSON(&input); err != nil {
+		newErrorResponse(c, fmt.Errorf("%w: %w", errInvalidRequest, err))
+		return
+	}
+
+	ids := make([]uuid.UUID, 0, len(input.SessionIDs))
+	for _, value := range input.SessionIDs {
+		id, err := uuid.FromString(value)
+		if err != nil {
+			newErrorResponse(c, invalidParam("session_ids", err))
+			return
+		}
+		ids = append(ids, id)
+	}
+
+	revoked, err := h.services.RevokeSessions(c, ids)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, revokedResponse{Revoked: revoked})
+}
+
+// GetUserEvents godoc
+// @Summary События безопасности пользователя
+// @Description Возвращает события пользователя от новых к старым: входы, обновления токенов, смену User-Agent и IP, отзывы сессий и принудительные выходы.
+// @Tags admin
+// @Security ApiKeyAuth
+// @Produce json
+// @Param Authorization header string true "Токен администратора в формате: Bearer <token>"
+// @Param id    path  string true  "GUID пользователя"
+// @Param limit query int    false "Максимум событий, по умолчанию 100, не больше 1000"
+// @Success 200 {array} entity.SecurityEvent "События"
+// @Failure 400 {object} Problem "Неверный параметр"
+// @Failure 401 {object} Problem "Неавторизованный доступ"
+// @Failure 403 {object} Problem "Нет роли admin или scope admin"
+// @Failure 500 {object} Problem "Внутренняя ошибка сервера"
+// @Router /admin/users/{id}/events [get]
+func (h *Handler) getUserEvents(c *gin.Context) {
+	userID, err := uuid.FromString(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, invalidParam("id", err))
+		return
+	}
+
+	limit, err := queryInt(c, "limit")
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	events, err := h.services.GetUserEvents(c, userID, limit)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, events)
+}
+
+// LogoutUser godoc
+// @Summary Принудительный выход пользователя
+// @Description Отзывает все активные сессии пользователя. Refresh токены перестают действовать сразу, access токены не проходят проверку сессии в /user и /userinfo.
+// @Tags admin
+// @Security ApiKeyAuth
+// @Produce json
+// @Param Authorization header string true "Токен администратора в формате: Bearer <token>"
+// @Param id path string true "GUID пользователя"
+// @Success 200 {object} revokedResponse "Количество отозванных сессий"
+// @Failure 400 {object} Problem "Неверный GUID"
+// @Failure 401 {object} Problem "Неавторизованный доступ"
+// @Failure 403 {object} Problem "Нет роли admin или scope admin"
+// @Failure 500 {object} Problem "Внутренняя ошибка сервера"
+// @Router /admin/users/{id}/logout [post]
+func (h *Handler) logoutUser(c *gin.Context) {
+	userID, err := uuid.FromString(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, invalidParam("id", err))
+		return
+	}
+
+	revoked, err := h.services.LogoutUser(c, userID)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, revokedResponse{Revoked: revoked})
+}
+
+func parseSessionFilter(c *gin.Context) (entity.SessionFilter, error) {
+	var (
+		filter entity.SessionFilter
+		err    error
+	)
+
+	if value := c.Query("user_id"); value != "" {
+		if filter.UserID, err = uuid.FromString(value); err != nil {
+			return filter, invalidParam("user_id", err)
+		}
+	}
+	if value := c.Query("ip"); value != "" {
+		if filter.IP = net.ParseIP(value); filter.IP == nil {
+			return filter, invalidParam("ip", fmt.Errorf("invalid ip %q", value))
+		}
+	}
+	filter.UserAgent = c.Query("user_agent")
+	if value := c.Query("from"); value != "" {
+		if filter.CreatedFrom, err = time.Parse(time.RFC3339, value); err != nil {
+			return filter, invalidParam("from", err)
+		}
+	}
+	if value := c.Query("to"); value != "" {
+		if filter.CreatedTo, err = time.Parse(time.RFC3339, value); err != nil {
+			return filter, invalidParam("to", err)
+		}
+	}
+	if value := c.Query("active"); value != "" {
+		if filter.ActiveOnly, err = strconv.ParseBool(value); err != nil {
+			return filter, invalidParam("active", err)
+		}
+	}
+	if filter.Limit, err = queryInt(c, "limit"); err != nil {
+		return filter, err
+	}
+
+	return filter, nil
+}
+
+func queryInt(c *gin.Context, key string) (int, error) {
+	value := c.Query(key)
+	if value == "" {
+		return 0, nil
+	}
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, invalidParam(key, err)
+	}
+	return number, nil
+}
+
+func newSessionResponse(session entity.Session) sessionResponse {
+	return sessionResponse{
+		ID:        session.ID.String(),
+		UserID:    session.UserId.String(),
+		IP:        session.IP.String(),
+		UserAgent: session.UserAgent,
+		Scope:     session.Scope,
+		CreatedAt: session.CreatedAt,
+		ExpiresAt: session.ExpiresAt,
+		IsRevoked: session.IsRevorked,
+	}
+}
@@ -0,0 +1,394 @@
+package handlers
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BabyJhon/medods-test-task/internal/entity"
+	"github.com/BabyJhon/medods-test-task/internal/logging"
+	"github.com/BabyJhon/medods-test-task/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/gofrs/uuid"
+)
+
+const (
+	consolePath       = "/console"
+	consoleCookie     = "console_token"
+	consoleCSRFCookie = "console_csrf"
+	consoleCSRFField  = "csrf_token"
+	csrfCtxKey        = "csrf_token"
+)
+
+//go:embed console
+var consoleFS embed.FS
+
+var consoleTemplates = parseConsoleTemplates()
+
+type consolePage struct {
+	Title     string
+	Path      string
+	Admin     string
+	CSRFToken string
+	Notice    string
+	Error     string
+	Data      any
+}
+
+type consoleUserPage struct {
+	UserID   string
+	Sessions []entity.Session
+	Events   []entity.SecurityEvent
+}
+
+type consoleSessionsPage struct {
+	Query    url.Values
+	Sessions []entity.Session
+}
+
+func parseConsoleTemplates() map[string]*template.Template {
+	funcs := template.FuncMap{
+		"formatTime": func(tm time.Time) string {
+			if tm.IsZero() {
+				return ""
+			}
+			return tm.Local().Format("02.01.2006 15:04:05")
+		},
+		"isActive": func(session entity.Session) bool {
+			return !session.IsRevorked && session.ExpiresAt.After(time.Now())
+		},
+	}
+
+	pages, err := fs.Glob(consoleFS, "console/templates/*.html")
+	if err != nil {
+		panic(err)
+	}
+
+	templates := make(map[string]*template.Template)
+	for _, page := range pages {
+		name := strings.TrimPrefix(page, "console/templates/")
+		if name == "layout.html" {
+			continue
+		}
+		templates[name] = template.Must(template.New(name).Funcs(funcs).ParseFS(consoleFS, "console/templates/layout.html", page))
+	}
+
+	return templates
+}
+
+// initConsoleRoutes registers the admin console. It is served by the admin router only.
+func (h *Handler) initConsoleRoutes(router *gin.Engine) {
+	static, err := fs.Sub(consoleFS, "console/static")
+	if err != nil {
+		panic(err)
+	}
+
+	console := router.Group(consolePath, consoleHeaders, consoleCSRFToken, consoleCSRF)
+	{
+		console.StaticFS("/static", http.FS(static))
+		console.GET("/login", h.consoleLoginForm)
+		console.POST("/login", h.consoleLogin)
+		console.POST("/logout", h.consoleLogout)
+
+		authorized := console.Group("", h.consoleAuthenticate)
+		{
+			authorized.GET("", func(c *gin.Context) {
+				c.Redirect(http.StatusFound, consolePath+"/users")
+			})
+			authorized.GET("/users", h.consoleUsers)
+			authorized.GET("/users/:id", h.consoleUser)
+			authorized.POST("/users/:id/logout", h.consoleLogoutUser)
+			authorized.GET("/sessions", h.consoleSessions)
+			authorized.POST("/sessions/revoke", h.consoleRevokeSessions)
+			authorized.GET("/webhooks", h.consoleWebhooks)
+		}
+	}
+}
+
+func (h *Handler) consoleLoginForm(c *gin.Context) {
+	h.renderConsole(c, http.StatusOK, "login.html", consolePage{Title: "Вход"})
+}
+
+// consoleLogin accepts an admin access token and keeps it in an httpOnly cookie until the
+// token expires. The console has no passwords of its own, admins get the token the same
+// way as for the admin API.
+func (h *Handler) consoleLogin(c *gin.Context) {
+	token := strings.TrimSpace(strings.TrimPrefix(c.PostForm("token"), "Bearer "))
+
+	claims, err := h.parseConsoleToken(c, token)
+	if err != nil {
+		logging.FromContext(c).Error(err.Error())
+		h.renderConsole(c, http.StatusUnauthorized, "login.html", consolePage{Title: "Вход", Error: err.Error()})
+		return
+	}
+
+	maxAge := int(time.Until(claims.ExpiresAt.Time).Seconds())
+	setConsoleCookie(c, consoleCookie, token, maxAge)
+	// Новый CSRF токен после входа, чтобы токен, известный до входа, не подошел к сессии.
+	setConsoleCookie(c, consoleCSRFCookie, newCSRFToken(), 0)
+
+	c.Redirect(http.StatusSeeOther, consolePath+"/users")
+}
+
+func (h *Handler) consoleLogout(c *gin.Context) {
+	setConsoleCookie(c, consoleCookie, "", -1)
+	setConsoleCookie(c, consoleCSRFCookie, "", -1)
+
+	c.Redirect(http.StatusSeeOther, consolePath+"/login")
+}
+
+// consoleAuthenticate is the console counterpart of authenticate: the token comes from the
+// cookie, and an unauthenticated browser is sent to the login page instead of getting a 401.
+func (h *Handler) consoleAuthenticate(c *gin.Context) {
+	token, err := c.Cookie(consoleCookie)
+	if err != nil {
+		c.Redirect(http.StatusFound, consolePath+"/login")
+		c.Abort()
+		return
+	}
+
+	claims, err := h.parseConsoleToken(c, token)
+	if err != nil {
+		logging.FromContext(c).Error(err.Error())
+		setConsoleCookie(c, consoleCookie, "", -1)
+		c.Redirect(http.StatusFound, consolePath+"/login")
+		c.Abort()
+		return
+	}
+
+	c.Set(claimsCtxKey, claims)
+	c.Next()
+}
+
+// parseConsoleToken checks the token the same way as the admin API middleware does and
+// additionally rejects tokens of revoked sessions.
+func (h *Handler) parseConsoleToken(c *gin.Context, token string) (*entity.Claimes, error) {
+	if token == "" {
+		return nil, errors.New("token is required")
+	}
+
+	claims, err := h.services.Parsetoken(token)
+	if err != nil {
+		return nil, err
+	}
+	// Консоль не подписывает DPoP доказательства, поэтому DPoP токены в нее не пускаются.
+	if err := service.VerifyConfirmation(claims, certificateConfirmation(c.Request)); err != nil {
+		return nil, err
+	}
+	if !slices.Contains(claims.Roles, service.RoleAdmin) {
+		return nil, fmt.Errorf("role %q is required", service.RoleAdmin)
+	}
+	if !slices.Contains(strings.Fields(claims.Scope), service.ScopeAdmin) {
+		return nil, fmt.Errorf("scope %q is required", service.ScopeAdmin)
+	}
+	if _, err := h.services.GetSession(c, *claims); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+func (h *Handler) consoleUsers(c *gin.Context) {
+	limit, err := queryInt(c, "limit")
+	if err != nil {
+		h.renderConsoleError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	users, err := h.services.ListUsers(c, limit)
+	if err != nil {
+		h.renderConsoleError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	h.renderConsole(c, http.StatusOK, "users.html", consolePage{Title: "Пользователи", Data: users})
+}
+
+func (h *Handler) consoleUser(c *gin.Context) {
+	userID, err := uuid.FromString(c.Param("id"))
+	if err != nil {
+		h.renderConsoleError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	sessions, err := h.services.ListUserSessions(c, userID)
+	if err != nil {
+		h.renderConsoleError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	events, err := h.services.GetUserEvents(c, userID, 0)
+	if err != nil {
+		h.renderConsoleError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	h.renderConsole(c, http.StatusOK, "user.html", consolePage{
+		Title: "Пользователь " + userID.String(),
+		Data: consoleUserPage{
+			UserID:   userID.String(),
+			Sessions: sessions,
+			Events:   events,
+		},
+	})
+}
+
+func (h *Handler) consoleLogoutUser(c *gin.Context) {
+	userID, err := uuid.FromString(c.Param("id"))
+	if err != nil {
+		h.renderConsoleError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	revoked, err := h.services.LogoutUser(c, userID)
+	if err != nil {
+		h.renderConsoleError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	redirectWithRevoked(c, consolePath+"/users/"+userID.String(), revoked)
+}
+
+func (h *Handler) consoleSessions(c *gin.Context) {
+	page := consolePage{Title: "Сессии"}
+
+	filter, err := parseSessionFilter(c)
+	if err != nil {
+		page.Error = err.Error()
+		page.Data = consoleSessionsPage{Query: c.Request.URL.Query()}
+		h.renderConsole(c, http.StatusBadRequest, "sessions.html", page)
+		return
+	}
+
+	sessions, err := h.services.SearchSessions(c, filter)
+	if err != nil {
+		h.renderConsoleError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	page.Data = consoleSessionsPage{Query: c.Request.URL.Query(), Sessions: sessions}
+	h.renderConsole(c, http.StatusOK, "sessions.html", page)
+}
+
+func (h *Handler) consoleRevokeSessions(c *gin.Context) {
+	ids := make([]uuid.UUID, 0)
+	for _, value := range c.PostFormArray("session_id") {
+		id, err := uuid.FromString(value)
+		if err != nil {
+			h.renderConsoleError(c, http.StatusBadRequest, err)
+			return
+		}
+		ids = append(ids, id)
+	}
+
+	revoked, err := h.services.RevokeSessions(c, ids)
+	if err != nil {
+		h.renderConsoleError(c, problemFor(err).Status, err)
+		return
+	}
+
+	returnTo := c.PostForm("return_to")
+	if !strings.HasPrefix(returnTo, consolePath+"/") {
+		returnTo = consolePath + "/sessions"
+	}
+	redirectWithRevoked(c, returnTo, revoked)
+}
+
+func (h *Handler) consoleWebhooks(c *gin.Context) {
+	limit, err := queryInt(c, "limit")
+	if err != nil {
+		h.renderConsoleError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	deliveries, err := h.services.ListDeliveries(c, limit)
+	if err != nil {
+		h.renderConsoleError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	h.renderConsole(c, http.StatusOK, "webhooks.html", consolePage{Title: "Вебхуки", Data: deliveries})
+}
+
+func (h *Handler) renderConsole(c *gin.Context, status int, name string, page consolePage) {
+	if claims, ok := getClaims(c); ok {
+		page.Admin = claims.Subject
+	}
+	page.Path = c.Request.URL.RequestURI()
+	page.CSRFToken = c.GetString(csrfCtxKey)
+	if revoked, err := strconv.ParseInt(c.Query("revoked"), 10, 64); err == nil {
+		page.Notice = fmt.Sprintf("Отозвано сессий: %d", revoked)
+	}
+
+	c.Status(status)
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	if err := consoleTemplates[name].ExecuteTemplate(c.Writer, "layout.html", page); err != nil {
+		logging.FromContext(c).Errorf("failed to render console page %s: %s", name, err.Error())
+	}
+}
+
+func (h *Handler) renderConsoleError(c *gin.Context, status int, err error) {
+	logging.FromContext(c).Error(err.Error())
+	h.renderConsole(c, status, "error.html", consolePage{Title: "Ошибка", Error: err.Error()})
+	c.Abort()
+}
+
+// consoleHeaders forbids framing, so one-click revocation can't be clickjacked, and limits
+// the pages to their own embedded assets.
+func consoleHeaders(c *gin.Context) {
+	c.Header("X-Frame-Options", "DENY")
+	c.Header("Content-Security-Policy", "default-src 'self'; frame-ancestors 'none'; form-action 'self'")
+	c.Header("Referrer-Policy", "same-origin")
+	c.Header("Cache-Control", "no-store")
+	c.Next()
+}
+
+// consoleCSRFToken issues the console_csrf cookie on the first visit and passes its value
+// to the templates, every form carries it in the csrf_token field.
+func consoleCSRFToken(c *gin.Context) {
+	token, err := c.Cookie(consoleCSRFCookie)
+	if err != nil || token == "" {
+		token = newCSRFToken()
+		setConsoleCookie(c, consoleCSRFCookie, token, 0)
+	}
+	c.Set(csrfCtxKey, token)
+	c.Next()
+}
+
+// consoleCSRF checks the console forms with the shared guard. Only the admin host itself
+// is trusted, frontends of the public API have no business posting to the console.
+var consoleCSRF = (&CSRFGuard{}).protect(consoleCSRFCookie, func(c *gin.Context) string {
+	return c.PostForm(consoleCSRFField)
+}, abortConsoleForbidden)
+
+func abortConsoleForbidden(c *gin.Context, message string) {
+	logging.FromContext(c).Error(message)
+	c.AbortWithStatus(http.StatusForbidden)
+}
+
+func setConsoleCookie(c *gin.Context, name, value string, maxAge int) {
+	c.SetSameSite(http.SameSiteStrictMode)
+	c.SetCookie(name, value, maxAge, consolePath, "", c.Request.TLS != nil, true)
+}
+
+// redirectWithRevoked redirects back to a console page, which then reports the number of
+// revoked sessions. Only the number is passed, so a link can't show arbitrary text.
+func redirectWithRevoked(c *gin.Context, location string, revoked int64) {
+	target, err := url.Parse(location)
+	if err != nil {
+		target = &url.URL{Path: consolePath}
+	}
+	query := target.Query()
+	query.Set("revoked", strconv.FormatInt(revoked, 10))
+	target.RawQuery = query.Encode()
+
+	c.Redirect(http.StatusSeeOther, target.String())
+}
@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/BabyJhon/medods-test-task/internal/entity"
+	"github.com/BabyJhon/medods-test-task/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	accessTokenCookie  = "access_token"
+	refreshTokenCookie = "refresh_token"
+	csrfTokenCookie    = "csrf_token"
+
+	CookiePrefixSecure = "__Secure-"
+	CookiePrefixHost   = "__Host-"
+)
+
+type CookieConfig struct {
+	// Domain is empty by default, which limits the cookies to the exact host of the API.
+	Domain string
+	// Path defaults to "/".
+	Path string
+	// RefreshPath scopes the refresh token cookie, for example to "/refresh", so browsers
+	// don't send it anywhere else. It is relative to the root of the API version that
+	// issues the tokens, /api/v1/refresh for v1. Defaults to Path.
+	RefreshPath string
+	// SameSite defaults to http.SameSiteLaxMode.
+	SameSite http.SameSite
+	// Insecure drops the Secure attribute, for local development over plain HTTP only.
+	Insecure bool
+	// Prefix is empty, CookiePrefixSecure or CookiePrefixHost. Browsers reject prefixed
+	// cookies that don't meet the prefix requirements, so they are checked up front.
+	Prefix string
+}
+
+// CookiePolicy sets and reads the token cookies the same way in every handler that
+// issues tokens.
+type CookiePolicy struct {
+	cfg CookieConfig
+}
+
+func NewCookiePolicy(cfg CookieConfig) (*CookiePolicy, error) {
+	if cfg.Path == "" {
+		cfg.Path = "/"
+	}
+	if cfg.RefreshPath == "" {
+		cfg.RefreshPath = cfg.Path
+	}
+	if cfg.SameSite == 0 || cfg.SameSite == http.SameSiteDefaultMode {
+		cfg.SameSite = http.SameSiteLaxMode
+	}
+
+	if cfg.SameSite == http.SameSiteNoneMode && cfg.Insecure {
+		return nil, errors.New("SameSite=None cookies must be Secure")
+	}
+	switch cfg.Prefix {
+	case "":
+	case CookiePrefixSecure:
+		if cfg.Insecure {
+			return nil, fmt.Errorf("%s cookies must be Secure", cfg.Prefix)
+		}
+	case CookiePrefixHost:
+		if cfg.Insecure || cfg.Domain != "" || cfg.Path != "/" || cfg.RefreshPath != "/" {
+			return nil, fmt.Errorf("%s cookies must be Secure, without Domain and with Path=/", cfg.Prefix)
+		}
+	default:
+		return nil, fmt.Errorf("unknown cookie prefix %q", cfg.Prefix)
+	}
+
+	return &CookiePolicy{cfg: cfg}, nil
+}
+
+func (p *CookiePolicy) AccessTokenName() string {
+	return p.cfg.Prefix + accessTokenCookie
+}
+
+func (p *CookiePolicy) RefreshTokenName() string {
+	return p.cfg.Prefix + refreshTokenCookie
+}
+
+func (p *CookiePolicy) CSRFTokenName() string {
+	return p.cfg.Prefix + csrfTokenCookie
+}
+
+// SetTokens sets both token cookies and a new CSRF token. The access token cookie lives as
+// long as the refresh token rather than the access token: refresh requires the expired
+// access token of the session, so the cookie must outlive the token in it.
+//
+// The CSRF cookie is readable by scripts so the frontend can echo it in X-CSRF-Token; the
+// same value is returned in the X-CSRF-Token response header for frontends on other hosts.
+func (p *CookiePolicy) SetTokens(c *gin.Context, tokens entity.TokenResponse) {
+	p.set(c, p.AccessTokenName(), tokens.AccessToken, p.cfg.Path, service.RefreshTokenTTL, true)
+	p.set(c, p.RefreshTokenName(), tokens.RefreshToken, p.refreshPath(c), service.RefreshTokenTTL, true)
+
+	csrfToken := newCSRFToken()
+	p.set(c, p.CSRFTokenName(), csrfToken, p.cfg.Path, service.RefreshTokenTTL, false)
+	c.Header(csrfHeader, csrfToken)
+}
+
+// refreshPath resolves RefreshPath against the API version of the request, so the cookie
+// reaches the refresh route of the same version. A RefreshPath equal to Path covers every
+// version already.
+func (p *CookiePolicy) refreshPath(c *gin.Context) string {
+	if p.cfg.RefreshPath == p.cfg.Path {
+		return p.cfg.RefreshPath
+	}
+	return c.GetString(apiBaseCtxKey) + p.cfg.RefreshPath
+}
+
+func (p *CookiePolicy) AccessToken(r *http.Request) (string, error) {
+	return cookieValue(r, p.AccessTokenName())
+}
+
+func (p *CookiePolicy) RefreshToken(r *http.Request) (string, error) {
+	return cookieValue(r, p.RefreshTokenName())
+}
+
+func (p *CookiePolicy) set(c *gin.Context, name, value, path string, ttl time.Duration, httpOnly bool) {
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		Domain:   p.cfg.Domain,
+		MaxAge:   int(ttl.Seconds()),
+		Expires:  time.Now().Add(ttl),
+		Secure:   !p.cfg.Insecure,
+		HttpOnly: httpOnly,
+		SameSite: p.cfg.SameSite,
+	})
+}
+
+func cookieValue(r *http.Request, name string) (string, error) {
+	cookie, err := r.Cookie(name)
+	if err != nil {
+		return "", fmt.Errorf("cookie %s: %w", name, err)
+	}
+	if cookie.Value == "" {
+		return "", fmt.Errorf("cookie %s is empty", name)
+	}
+	return cookie.Value, nil
+}
+
+// ParseSameSite parses lax, strict or none, empty meaning the default.
+func ParseSameSite(value string) (http.SameSite, error) {
+	switch strings.ToLower(value) {
+	case "":
+		return http.SameSiteDefaultMode, nil
+	case "lax":
+		return http.SameSiteLaxMode, nil
+	case "strict":
+		return http.SameSiteStrictMode, nil
+	case "none":
+		return http.SameSiteNoneMode, nil
+	default:
+		return 0, fmt.Errorf("unknown SameSite mode %q", value)
+	}
+}
@@ -0,0 +1,235 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// CORSAnyOrigin allows every origin. It can't be combined with credentials.
+	CORSAnyOrigin = "*"
+
+	defaultCORSMaxAge = 10 * time.Minute
+)
+
+var (
+	defaultCORSMethods        = []string{http.MethodGet, http.MethodPost}
+	defaultCORSHeaders        = []string{"Authorization", "Content-Type", "DPoP", csrfHeader}
+	defaultCORSExposedHeaders = []string{csrfHeader, "WWW-Authenticate"}
+)
+
+type CORSConfig struct {
+	// AllowedOrigins are origins like https://app.example.com. A wildcard in place of the
+	// leftmost label, https://*.example.com, matches every subdomain but not the domain
+	// itself. CORSAnyOrigin allows every origin.
+	AllowedOrigins []string
+	// AllowCredentials lets browsers send and receive cookies, needed for the cookie flows.
+	AllowCredentials bool
+	// AllowedMethods defaults to GET and POST.
+	AllowedMethods []string
+	// AllowedHeaders are the request headers scripts may set. Defaults to Authorization,
+	// Content-Type, DPoP and X-CSRF-Token.
+	AllowedHeaders []string
+	// ExposedHeaders are the response headers scripts may read. Defaults to X-CSRF-Token
+	// and WWW-Authenticate.
+	ExposedHeaders []string
+	// MaxAge is how long browsers may cache a preflight response, 10 minutes by default.
+	MaxAge time.Duration
+}
+
+// CORS answers preflight requests and adds CORS headers to the responses of a route group.
+type CORS struct {
+	anyOrigin      bool
+	origins        map[string]struct{}
+	wildcards      []wildcardOrigin
+	credentials    bool
+	methods        []string
+	headers        []string
+	exposedHeaders string
+	maxAge         string
+}
+
+// wildcardOrigin is https://*.example.com split into scheme and the ".example.com" suffix.
+type wildcardOrigin struct {
+	scheme string
+	suffix string
+}
+
+func NewCORS(cfg CORSConfig) (*CORS, error) {
+	if len(cfg.AllowedOrigins) == 0 {
+		return nil, errors.New("at least one allowed origin is required")
+	}
+	if cfg.AllowedMethods == nil {
+		cfg.AllowedMethods = defaultCORSMethods
+	}
+	if cfg.AllowedHeaders == nil {
+		cfg.AllowedHeaders = defaultCORSHeaders
+	}
+	if cfg.ExposedHeaders == nil {
+		cfg.ExposedHeaders = defaultCORSExposedHeaders
+	}
+	if cfg.MaxAge == 0 {
+		cfg.MaxAge = defaultCORSMaxAge
+	}
+
+	p := &CORS{
+		origins:        make(map[string]struct{}),
+		credentials:    cfg.AllowCredentials,
+		headers:        cfg.AllowedHeaders,
+		exposedHeaders: strings.Join(cfg.ExposedHeaders, ", "),
+		maxAge:         strconv.Itoa(int(cfg.MaxAge.Seconds())),
+	}
+	for _, method := range cfg.AllowedMethods {
+		p.methods = append(p.methods, strings.ToUpper(method))
+	}
+
+	for _, origin := range cfg.AllowedOrigins {
+		if origin == CORSAnyOrigin {
+			// Браузеры не принимают "*" с credentials, а отражать любой origin значит
+			// открыть cookie-сессии любому сайту.
+			if cfg.AllowCredentials {
+				return nil, errors.New("any origin can't be allowed with credentials")
+			}
+			p.anyOrigin = true
+			continue
+		}
+
+		u, err := url.Parse(origin)
+		if err != nil || u.Scheme == "" || u.Host == "" || (u.Path != "" && u.Path != "/") {
+			return nil, fmt.Errorf("invalid origin %q, want scheme://host[:port]", origin)
+		}
+		scheme, host := strings.ToLower(u.Scheme), strings.ToLower(u.Host)
+
+		if rest, ok := strings.CutPrefix(host, "*."); ok {
+			if rest == "" || strings.Contains(rest, "*") {
+				return nil, fmt.Errorf("invalid wildcard origin %q", origin)
+			}
+			p.wildcards = append(p.wildcards, wildcardOrigin{scheme: scheme, suffix: "." + rest})
+			continue
+		}
+		if strings.Contains(host, "*") {
+			return nil, fmt.Errorf("invalid origin %q, a wildcard may only replace the leftmost label", origin)
+		}
+		p.origins[scheme+"://"+host] = struct{}{}
+	}
+
+	return p, nil
+}
+
+// handler returns the middleware of the group. A nil policy adds no headers, so browsers
+// block cross-origin calls to the group.
+func (p *CORS) handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if p == nil {
+			c.Next()
+			return
+		}
+
+		c.Writer.Header().Add("Vary", "Origin")
+		origin := c.GetHeader("Origin")
+		if origin == "" {
+			c.Next()
+			return
+		}
+
+		preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+		if !p.allowedOrigin(origin) {
+			if preflight {
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+			c.Next()
+			return
+		}
+
+		if p.anyOrigin && !p.credentials {
+			c.Header("Access-Control-Allow-Origin", CORSAnyOrigin)
+		} else {
+			c.Header("Access-Control-Allow-Origin", origin)
+		}
+		if p.credentials {
+			c.Header("Access-Control-Allow-Credentials", "true")
+		}
+
+		if !preflight {
+			if p.exposedHeaders != "" {
+				c.Header("Access-Control-Expose-Headers", p.exposedHeaders)
+			}
+			c.Next()
+			return
+		}
+
+		c.Writer.Header().Add("Vary", "Access-Control-Request-Method")
+		c.Writer.Header().Add("Vary", "Access-Control-Request-Headers")
+		if !slices.Contains(p.methods, c.GetHeader("Access-Control-Request-Method")) {
+			c.AbortWithStatus(http.StatusForbidden)
+			return
+		}
+		for _, header := range strings.Split(c.GetHeader("Access-Control-Request-Headers"), ",") {
+			if header = strings.TrimSpace(header); header != "" && !slices.ContainsFunc(p.headers, func(allowed string) bool {
+				return strings.EqualFold(allowed, header)
+			}) {
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+		}
+
+		c.Header("Access-Control-Allow-Methods", strings.Join(p.methods, ", "))
+		if len(p.headers) > 0 {
+			c.Header("Access-Control-Allow-Headers", strings.Join(p.headers, ", "))
+		}
+		c.Header("Access-Control-Max-Age", p.maxAge)
+		c.AbortWithStatus(http.StatusNoContent)
+	}
+}
+
+func (p *CORS) allowedOrigin(origin string) bool {
+	if p.anyOrigin {
+		return true
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	scheme, host := strings.ToLower(u.Scheme), strings.ToLower(u.Host)
+
+	if _, ok := p.origins[scheme+"://"+host]; ok {
+		return true
+	}
+	for _, wildcard := range p.wildcards {
+		if scheme == wildcard.scheme && strings.HasSuffix(host, wildcard.suffix) && len(host) > len(wildcard.suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// CORSRoutes holds the CORS policies of the public route groups. A nil policy disables
+// CORS for its group.
+type CORSRoutes struct {
+	// Auth covers /auth, /refresh, /user, /revoke and /userinfo.
+	Auth *CORS
+	// OAuth covers /oauth/token, used by browser-based public clients.
+	OAuth *CORS
+	// Discovery covers the /.well-known documents.
+	Discovery *CORS
+}
+
+// handlePreflight registers OPTIONS routes for the paths of the group, so that preflight
+// requests reach its CORS middleware instead of a 404.
+func handlePreflight(group *gin.RouterGroup, paths ...string) {
+	for _, path := range paths {
+		group.OPTIONS(path, func(c *gin.Context) {
+			c.Status(http.StatusNoContent)
+		})
+	}
+}
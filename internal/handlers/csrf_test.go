@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequireCSRF(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cookies, err := NewCookiePolicy(CookieConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	csrf, err := NewCSRFGuard([]string{"https://app.example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := &Handler{cookies: cookies, csrf: csrf}
+
+	router := gin.New()
+	router.POST("/refresh", h.requireCSRF(), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	const token = "csrf-token"
+	tests := []struct {
+		name    string
+		target  string
+		headers map[string]string
+		cookie  string
+		status  int
+	}{
+		{
+			name:    "same host",
+			headers: map[string]string{"Origin": "https://api.example.com", csrfHeader: token},
+			cookie:  token,
+			status:  http.StatusOK,
+		},
+		{
+			name:    "trusted origin",
+			headers: map[string]string{"Origin": "https://app.example.com", csrfHeader: token},
+			cookie:  token,
+			status:  http.StatusOK,
+		},
+		{
+			name:    "non-browser client without origin and referer",
+			headers: map[string]string{csrfHeader: token},
+			cookie:  token,
+			status:  http.StatusOK,
+		},
+		{
+			name:    "foreign origin",
+			headers: map[string]string{"Origin": "https://evil.example.com", csrfHeader: token},
+			cookie:  token,
+			status:  http.StatusForbidden,
+		},
+		{
+			name:    "trusted host with another scheme",
+			headers: map[string]string{"Origin": "http://app.example.com", csrfHeader: token},
+			cookie:  token,
+			status:  http.StatusForbidden,
+		},
+		{
+			name:    "foreign referer without origin",
+			headers: map[string]string{"Referer": "https://evil.example.com/page", csrfHeader: token},
+			cookie:  token,
+			status:  http.StatusForbidden,
+		},
+		{
+			name:    "null origin",
+			headers: map[string]string{"Origin": "null", csrfHeader: token},
+			cookie:  token,
+			status:  http.StatusForbidden,
+		},
+		{
+			name:    "missing token",
+			headers: map[string]string{"Origin": "https://api.example.com"},
+			cookie:  token,
+			status:  http.StatusForbidden,
+		},
+		{
+			name:    "mismatched token",
+			headers: map[string]string{"Origin": "https://api.example.com", csrfHeader: "other-token"},
+			cookie:  token,
+			status:  http.StatusForbidden,
+		},
+		{
+			name:    "missing cookie",
+			headers: map[string]string{"Origin": "https://api.example.com", csrfHeader: token},
+			status:  http.StatusForbidden,
+		},
+		{
+			name:    "json delivery",
+			target:  "/refresh?delivery=json",
+			headers: map[string]string{"Origin": "https://evil.example.com"},
+			status:  http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := tt.target
+			if target == "" {
+				target = "/refresh"
+			}
+			req := httptest.NewRequest(http.MethodPost, "http://api.example.com"+target, nil)
+			for name, value := range tt.headers {
+				req.Header.Set(name, value)
+			}
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: cookies.CSRFTokenName(), Value: tt.cookie})
+			}
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tt.status {
+				t.Fatalf("got status %d, want %d: %s", w.Code, tt.status, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestRequireCSRFSafeMethods(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cookies, err := NewCookiePolicy(CookieConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	csrf, err := NewCSRFGuard(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := &Handler{cookies: cookies, csrf: csrf}
+
+	router := gin.New()
+	router.GET("/me", h.requireCSRF(), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "http://api.example.com/me", nil)
+	req.Header.Set("Origin", "https://evil.example.com")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("got status %d, want %d", w.Code, http.StatusOK)
+	}
+}
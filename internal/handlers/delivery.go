@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/BabyJhon/medods-test-task/internal/entity"
+	"github.com/BabyJhon/medods-test-task/pkg/dpop"
+	"github.com/gin-gonic/gin"
+)
+
+// Token delivery modes. Browsers get the tokens in httpOnly cookies; native and server
+// clients, which can't keep such cookies, ask for them in the response body instead.
+const (
+	deliveryParam  = "delivery"
+	deliveryCookie = "cookie"
+	deliveryJSON   = "json"
+)
+
+type refreshInput struct {
+	RefreshToken string `json:"refresh_token" form:"refresh_token"`
+	// AccessToken may also be passed in the Authorization header.
+	AccessToken string `json:"access_token" form:"access_token"`
+}
+
+// tokenDelivery returns the delivery mode requested by the delivery query or form
+// parameter, cookie by default.
+func tokenDelivery(c *gin.Context) (string, error) {
+	mode := c.Query(deliveryParam)
+	if mode == "" {
+		mode = c.PostForm(deliveryParam)
+	}
+
+	switch mode {
+	case "", deliveryCookie:
+		return deliveryCookie, nil
+	case deliveryJSON:
+		return deliveryJSON, nil
+	default:
+		return "", fmt.Errorf("unknown token delivery %q, want %s or %s", mode, deliveryCookie, deliveryJSON)
+	}
+}
+
+// deliverTokens writes the tokens in cookies or, in json mode, in the response body.
+func (h *Handler) deliverTokens(c *gin.Context, tokens entity.TokenResponse, delivery string) {
+	if delivery != deliveryJSON {
+		h.cookies.SetTokens(c, tokens)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+	c.JSON(http.StatusOK, tokens)
+}
+
+// refreshCredentials returns the refresh token and the access token of the session: from
+// the cookies by default, from the request body and the Authorization header in json mode.
+func (h *Handler) refreshCredentials(c *gin.Context, delivery string) (string, string, error) {
+	if delivery != deliveryJSON {
+		refreshToken, err := h.cookies.RefreshToken(c.Request)
+		if err != nil {
+			return "", "", fmt.Errorf("%w: %w", errMissingToken, err)
+		}
+		accessToken, err := h.cookies.AccessToken(c.Request)
+		if err != nil {
+			return "", "", fmt.Errorf("%w: %w", errMissingToken, err)
+		}
+		return refreshToken, accessToken, nil
+	}
+
+	var input refreshInput
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBind(&input); err != nil {
+			return "", "", fmt.Errorf("%w: %w", errInvalidRequest, err)
+		}
+	}
+	if input.RefreshToken == "" {
+		return "", "", fmt.Errorf("%w: refresh_token is required", errMissingToken)
+	}
+
+	accessToken := authorizationToken(c)
+	if accessToken == "" {
+		accessToken = input.AccessToken
+	}
+	if accessToken == "" {
+		return "", "", fmt.Errorf("%w: access token is required", errMissingToken)
+	}
+
+	return input.RefreshToken, accessToken, nil
+}
+
+// authorizationToken returns the token of a Bearer or DPoP Authorization header, or an
+// empty string.
+func authorizationToken(c *gin.Context) string {
+	headerParts := strings.Split(c.GetHeader(authoriationHeader), " ")
+	if len(headerParts) != 2 || !(strings.EqualFold(headerParts[0], "Bearer") || strings.EqualFold(headerParts[0], dpop.Scheme)) {
+		return ""
+	}
+	return headerParts[1]
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Readiness reports whether the service should receive traffic. It fails while the
+// service is starting or shutting down.
+type Readiness interface {
+	Ready() bool
+}
+
+type healthStatus struct {
+	Status string `json:"status"`
+}
+
+// Пробы не версионируются: их адреса зашиты в конфигурацию оркестратора.
+func (h *Handler) initHealthRoutes(router *gin.Engine) {
+	router.GET("/healthz", h.liveness)
+	router.GET("/readyz", h.readiness)
+}
+
+// liveness only reports that the process serves requests.
+func (h *Handler) liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, healthStatus{Status: "ok"})
+}
+
+func (h *Handler) readiness(c *gin.Context) {
+	if h.ready == nil || !h.ready.Ready() {
+		c.JSON(http.StatusServiceUnavailable, healthStatus{Status: "unavailable"})
+		return
+	}
+	c.JSON(http.StatusOK, healthStatus{Status: "ok"})
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"context"
+	"net"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/BabyJhon/medods-test-task/internal/i18n"
+	"github.com/BabyJhon/medods-test-task/internal/repo/memory"
+	"github.com/BabyJhon/medods-test-task/internal/service"
+	"github.com/BabyJhon/medods-test-task/pkg/authmw"
+	"github.com/gin-gonic/gin"
+	"github.com/gofrs/uuid"
+)
+
+// TestAccessTokensVerifyWithJWKS checks that downstream services verify access tokens with
+// nothing but the published JWK Set.
+func TestAccessTokensVerifyWithJWKS(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("SIGNING_KEY", "")
+
+	key, err := service.GenerateSigningKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	services := service.NewService(memory.NewRepository(), []*service.SigningKey{key}, i18n.Translator{})
+	h := &Handler{services: services}
+
+	router := gin.New()
+	router.GET("/.well-known/jwks.json", h.jwks)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	verifier, err := authmw.NewVerifier(authmw.Config{JWKSURL: server.URL + "/.well-known/jwks.json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	guid := uuid.Must(uuid.NewV4())
+	tokens, err := services.CreateTokens(context.Background(), guid, "test", net.ParseIP("127.0.0.1"), "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := verifier.Verify(context.Background(), tokens.AccessToken)
+	if err != nil {
+		t.Fatalf("access token: %v", err)
+	}
+	if claims.Subject != guid.String() {
+		t.Errorf("subject = %q, want %q", claims.Subject, guid)
+	}
+
+	idToken, err := services.GenerateIDToken(guid, "client", "", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := verifier.Verify(context.Background(), idToken); err == nil {
+		t.Error("ID token was accepted as an access token")
+	}
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/BabyJhon/medods-test-task/internal/logging"
+	"github.com/gin-gonic/gin"
+	"github.com/gofrs/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	requestIDHeader = "X-Request-ID"
+
+	maxRequestIDLength = 128
+)
+
+// requestID takes the request ID from X-Request-ID, set by a proxy or the client, or
+// generates one. The ID is echoed in the response and carried in the request context, so
+// every log entry of the request has it.
+func requestID(c *gin.Context) {
+	id := c.GetHeader(requestIDHeader)
+	if !validRequestID(id) {
+		generated, err := uuid.DefaultGenerator.NewV4()
+		if err != nil {
+			newErrorResponse(c, err)
+			return
+		}
+		id = generated.String()
+	}
+
+	c.Header(requestIDHeader, id)
+	c.Request = c.Request.WithContext(logging.WithFields(c.Request.Context(), logrus.Fields{logging.RequestIDField: id}))
+	c.Next()
+}
+
+// validRequestID accepts IDs of printable ASCII without spaces, so a client can't inject
+// anything into the logs through the header.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		if r <= ' ' || r > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// setUserID adds the user of the request to its log entries, the access log included.
+// The ID is hashed when the entries are written.
+func setUserID(c *gin.Context, userID string) {
+	c.Request = c.Request.WithContext(logging.WithFields(c.Request.Context(), logrus.Fields{logging.UserIDField: userID}))
+}
+
+// accessLog writes one entry per request. route is the route pattern rather than the
+// path, so IDs in the path don't reach the logs.
+func accessLog(c *gin.Context) {
+	start := time.Now()
+	c.Next()
+
+	logging.FromContext(c).WithFields(logrus.Fields{
+		"method":     c.Request.Method,
+		"route":      c.FullPath(),
+		"status":     c.Writer.Status(),
+		"latency_ms": float64(time.Since(start).Microseconds()) / 1000,
+		"client_ip":  c.ClientIP(),
+	}).Info("request")
+}
+
+// recovery turns a panic of a handler into a 500 problem response instead of a dropped
+// connection.
+func recovery(c *gin.Context) {
+	defer func() {
+		recovered := recover()
+		if recovered == nil {
+			return
+		}
+		// http.ErrAbortHandler намеренно обрывает ответ, его обрабатывает net/http.
+		if recovered == http.ErrAbortHandler {
+			panic(recovered)
+		}
+
+		err := fmt.Errorf("panic: %v\n%s", recovered, debug.Stack())
+		if c.Writer.Written() {
+			logging.FromContext(c).Error(err.Error())
+			c.Abort()
+			return
+		}
+		newErrorResponse(c, err)
+	}()
+
+	c.Next()
+}
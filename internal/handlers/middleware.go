@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/BabyJhon/medods-test-task/internal/entity"
+	"github.com/BabyJhon/medods-test-task/internal/i18n"
+	"github.com/BabyJhon/medods-test-task/pkg/dpop"
+	"github.com/BabyJhon/medods-test-task/pkg/httpserver"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	claimsCtxKey     = "claims"
+	translatorCtxKey = "translator"
+)
+
+// localize picks the language of the messages for the request from Accept-Language.
+func (h *Handler) localize(c *gin.Context) {
+	c.Set(translatorCtxKey, h.messages.Translator(h.messages.Match(c.GetHeader("Accept-Language"))))
+	c.Next()
+}
+
+// translator returns the translator chosen by localize. Without one messages are
+// rendered as their keys.
+func translator(c *gin.Context) i18n.Translator {
+	messages, _ := c.Value(translatorCtxKey).(i18n.Translator)
+	return messages
+}
+
+// authenticate parses the access token from the Authorization header and puts its claims
+// into the gin context for requireScopes, requireRoles and the handlers.
+func (h *Handler) authenticate(c *gin.Context) {
+	tokenClaimes, ok := h.parseAuthorizationHeader(c)
+	if !ok {
+		return
+	}
+
+	c.Set(claimsCtxKey, tokenClaimes)
+	c.Next()
+}
+
+// requireScopes allows the request only if the token carries all of the scopes.
+func requireScopes(scopes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenClaimes, ok := getClaims(c)
+		if !ok {
+			newErrorResponse(c, errMissingToken)
+			return
+		}
+
+		granted := strings.Fields(tokenClaimes.Scope)
+		for _, scope := range scopes {
+			if !slices.Contains(granted, scope) {
+				c.Header("WWW-Authenticate", fmt.Sprintf(`Bearer error="insufficient_scope", scope="%s"`, strings.Join(scopes, " ")))
+				newErrorResponse(c, fmt.Errorf("%w: scope %q is required", errInsufficientScope, scope))
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// requireRoles allows the request if the token carries at least one of the roles.
+func requireRoles(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenClaimes, ok := getClaims(c)
+		if !ok {
+			newErrorResponse(c, errMissingToken)
+			return
+		}
+
+		for _, role := range roles {
+			if slices.Contains(tokenClaimes.Roles, role) {
+				c.Next()
+				return
+			}
+		}
+
+		newErrorResponse(c, fmt.Errorf("%w: one of roles %v is required", errInsufficientRole, roles))
+	}
+}
+
+// certificateConfirmation returns the cnf claim for the client certificate of the request,
+// or nil if the client didn't present one.
+func certificateConfirmation(r *http.Request) *entity.Confirmation {
+	cert, ok := httpserver.ClientCertificate(r)
+	if !ok {
+		return nil
+	}
+	return &entity.Confirmation{X5tS256: httpserver.CertificateThumbprint(cert)}
+}
+
+// requestConfirmation returns the cnf claim for the client certificate and the DPoP proof
+// of the request, or nil if it has neither. accessToken is empty where tokens are issued
+// and the presented token on protected routes, where the proof must carry its hash.
+func (h *Handler) requestConfirmation(c *gin.Context, accessToken string) (*entity.Confirmation, error) {
+	cnf := certificateConfirmation(c.Request)
+
+	proof, err := dpop.FromRequest(c.Request)
+	if err != nil {
+		return nil, err
+	}
+	if proof == "" {
+		return cnf, nil
+	}
+
+	jkt, err := h.services.VerifyDPoPProof(proof, c.Request.Method, h.services.DPoPRequestURL(c.Request), accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if cnf == nil {
+		cnf = &entity.Confirmation{}
+	}
+	cnf.JKT = jkt
+	return cnf, nil
+}
+
+func getClaims(c *gin.Context) (*entity.Claimes, bool) {
+	value, exists := c.Get(claimsCtxKey)
+	if !exists {
+		return nil, false
+	}
+
+	tokenClaimes, ok := value.(*entity.Claimes)
+	return tokenClaimes, ok
+}
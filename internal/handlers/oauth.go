@@ -0,0 +1,282 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+
+	"github.com/BabyJhon/medods-test-task/internal/entity"
+	"github.com/BabyJhon/medods-test-task/internal/logging"
+	"github.com/BabyJhon/medods-test-task/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/gofrs/uuid"
+)
+
+// Authorize godoc
+// @Summary      Авторизация OAuth 2.0 (authorization code + PKCE)
+// @Description  Проверяет клиента и redirect_uri, выдает одноразовый authorization code и перенаправляет на redirect_uri с параметрами code и state. Поддерживается только code_challenge_method=S256.
+// @Tags         oauth
+// @Param        response_type         query string true "Должен быть code"
+// @Param        client_id             query string true "Идентификатор клиента"
+// @Param        redirect_uri          query string true "Зарегистрированный redirect URI клиента"
+// @Param        state                 query string true "Значение state, возвращается клиенту без изменений"
+// @Param        code_challenge        query string true "BASE64URL(SHA256(code_verifier))"
+// @Param        code_challenge_method query string true "Должен быть S256"
+// @Param        scope                 query string false "Запрашиваемые scope через пробел, openid для получения ID токена"
+// @Param        nonce                 query string false "Значение nonce, попадает в ID токен"
+// @Param        guid                  query string true "GUID пользователя" example(1e44baa9-e04b-4739-89f3-3d86b9a272ce)
+// @Success      302  {string}  string "Перенаправление на redirect_uri с code и state"
+// @Failure      400  {object}  OAuthError "Неизвестный клиент или незарегистрированный redirect_uri"
+// @Router       /oauth/authorize [get]
+func (h *Handler) authorize(c *gin.Context) {
+	req := entity.AuthorizeRequest{
+		ResponseType:        c.Query("response_type"),
+		ClientID:            c.Query("client_id"),
+		RedirectURI:         c.Query("redirect_uri"),
+		State:               c.Query("state"),
+		CodeChallenge:       c.Query("code_challenge"),
+		CodeChallengeMethod: c.Query("code_challenge_method"),
+		Scope:               c.Query("scope"),
+		Nonce:               c.Query("nonce"),
+	}
+
+	// Пока клиент и redirect_uri не проверены, перенаправлять никуда нельзя.
+	if err := h.services.ValidateClient(c, req.ClientID, req.RedirectURI); err != nil {
+		if oauthErrorCode(err) == "server_error" {
+			newOAuthErrorResponse(c, http.StatusInternalServerError, "server_error", err)
+			return
+		}
+		newOAuthErrorResponse(c, http.StatusBadRequest, "invalid_request", err)
+		return
+	}
+
+	redirectURI, err := url.Parse(req.RedirectURI)
+	if err != nil {
+		newOAuthErrorResponse(c, http.StatusBadRequest, "invalid_request", err)
+		return
+	}
+
+	guid, err := uuid.FromString(c.Query("guid"))
+	if err != nil {
+		redirectWithParams(c, redirectURI, map[string]string{
+			"error":             "invalid_request",
+			"error_description": "invalid guid query param",
+			"state":             req.State,
+		})
+		return
+	}
+	req.UserID = guid
+	setUserID(c, guid.String())
+
+	code, err := h.services.Authorize(c, req)
+	if err != nil {
+		errorCode := oauthErrorCode(err)
+		if errorCode == "server_error" {
+			logging.FromContext(c).Error(err.Error())
+		} else {
+			logging.FromContext(c).Warn(err.Error())
+		}
+		redirectWithParams(c, redirectURI, map[string]string{
+			"error":             errorCode,
+			"error_description": oauthErrorDescriptions[errorCode],
+			"state":             req.State,
+		})
+		return
+	}
+
+	redirectWithParams(c, redirectURI, map[string]string{
+		"code":  code,
+		"state": req.State,
+	})
+}
+
+// Token godoc
+// @Summary      Выдача токенов OAuth 2.0
+// @Description  Обменивает authorization code (grant_type=authorization_code) или refresh токен (grant_type=refresh_token) на новую пару токенов. Если при авторизации был запрошен scope openid, дополнительно выдается ID токен. Токены возвращаются в теле ответа и, если не передан delivery=json, в httpOnly cookie. Для grant_type=refresh_token текущий access токен передается в заголовке Authorization или в cookie access_token. Для grant_type=client_credentials выдается только access токен клиента без пользовательской сессии. Конфиденциальные клиенты передают секрет через HTTP Basic или client_secret.
+// @Tags         oauth
+// @Accept       x-www-form-urlencoded
+// @Produce      json
+// @Param        grant_type    formData string true  "authorization_code или refresh_token"
+// @Param        code          formData string false "Authorization code (authorization_code)"
+// @Param        redirect_uri  formData string false "redirect_uri, использованный при авторизации (authorization_code)"
+// @Param        client_id     formData string false "Идентификатор клиента (authorization_code, client_credentials)"
+// @Param        client_secret formData string false "Секрет конфиденциального клиента, если не передан через HTTP Basic"
+// @Param        code_verifier formData string false "PKCE code_verifier (authorization_code)"
+// @Param        refresh_token formData string false "Refresh токен (refresh_token)"
+// @Param        scope         formData string false "Запрашиваемые scope через пробел (client_credentials)"
+// @Param        delivery      formData string false "json, чтобы не устанавливать cookie (нативные и серверные клиенты)"
+// @Param        DPoP          header   string false "DPoP доказательство (RFC 9449). Обязательно для клиентов с dpop_bound_access_tokens"
+// @Success      200  {object}  entity.TokenResponse "Токены успешно созданы"
+// @Failure      400  {object}  OAuthError "Неверный запрос или grant"
+// @Failure      401  {object}  OAuthError "Ошибка аутентификации клиента"
+// @Failure      500  {object}  OAuthError "Внутренняя ошибка сервера"
+// @Router       /oauth/token [post]
+func (h *Handler) token(c *gin.Context) {
+	userAgent := c.GetHeader("User-Agent")
+	clientIP := net.ParseIP(c.ClientIP())
+
+	req := entity.TokenRequest{
+		GrantType:    c.PostForm("grant_type"),
+		Code:         c.PostForm("code"),
+		RedirectURI:  c.PostForm("redirect_uri"),
+		ClientID:     c.PostForm("client_id"),
+		ClientSecret: c.PostForm("client_secret"),
+		CodeVerifier: c.PostForm("code_verifier"),
+		Scope:        c.PostForm("scope"),
+	}
+	if clientID, clientSecret, ok := c.Request.BasicAuth(); ok {
+		req.ClientID, req.ClientSecret = clientID, clientSecret
+	}
+
+	delivery, err := tokenDelivery(c)
+	if err != nil {
+		newOAuthErrorResponse(c, http.StatusBadRequest, "invalid_request", err)
+		return
+	}
+
+	var response entity.TokenResponse
+
+	// Доказательство проверяется один раз: повторная проверка отклонила бы его как replay.
+	req.Confirmation, err = h.requestConfirmation(c, "")
+	if err != nil {
+		newOAuthErrorResponse(c, oauthErrorStatus(err), oauthErrorCode(err), err)
+		return
+	}
+
+	switch req.GrantType {
+	case service.GrantTypeAuthorizationCode:
+		response, err = h.services.ExchangeCode(c, req, userAgent, clientIP)
+	case service.GrantTypeRefreshToken:
+		response, err = h.refreshGrant(c, userAgent, clientIP, req.Confirmation)
+	case service.GrantTypeClientCredentials:
+		response, err = h.services.ClientCredentials(c, req)
+	default:
+		newOAuthErrorResponse(c, http.StatusBadRequest, "unsupported_grant_type", fmt.Errorf("unsupported grant_type %q", req.GrantType))
+		return
+	}
+	if err != nil {
+		newOAuthErrorResponse(c, oauthErrorStatus(err), oauthErrorCode(err), err)
+		return
+	}
+
+	// У client_credentials нет пользовательской сессии, cookie нужны только браузерным клиентам.
+	if response.RefreshToken != "" && delivery == deliveryCookie {
+		h.cookies.SetTokens(c, response)
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+	c.JSON(http.StatusOK, response)
+}
+
+// Introspect godoc
+// @Summary      Интроспекция токена (RFC 7662)
+// @Description  Проверяет подпись и срок действия access токена, а также что его сессия не отозвана. Доступно только конфиденциальным клиентам, секрет передается через HTTP Basic или client_secret.
+// @Tags         oauth
+// @Accept       x-www-form-urlencoded
+// @Produce      json
+// @Param        token         formData string true  "Access токен"
+// @Param        client_id     formData string false "Идентификатор клиента, если не передан через HTTP Basic"
+// @Param        client_secret formData string false "Секрет клиента, если не передан через HTTP Basic"
+// @Success      200  {object}  entity.IntrospectionResponse "Состояние токена"
+// @Failure      400  {object}  OAuthError "Неверный запрос"
+// @Failure      401  {object}  OAuthError "Ошибка аутентификации клиента"
+// @Router       /oauth/introspect [post]
+func (h *Handler) introspect(c *gin.Context) {
+	req := entity.TokenRequest{
+		ClientID:     c.PostForm("client_id"),
+		ClientSecret: c.PostForm("client_secret"),
+	}
+	if clientID, clientSecret, ok := c.Request.BasicAuth(); ok {
+		req.ClientID, req.ClientSecret = clientID, clientSecret
+	}
+
+	response, err := h.services.Introspect(c, req, c.PostForm("token"))
+	if err != nil {
+		newOAuthErrorResponse(c, oauthErrorStatus(err), oauthErrorCode(err), err)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *Handler) refreshGrant(c *gin.Context, userAgent string, clientIP net.IP, cnf *entity.Confirmation) (entity.TokenResponse, error) {
+	refreshToken := c.PostForm("refresh_token")
+	if refreshToken == "" {
+		return entity.TokenResponse{}, fmt.Errorf("%w: refresh_token is required", service.ErrInvalidRequest)
+	}
+
+	accessToken := authorizationToken(c)
+	if accessToken == "" {
+		if cookieToken, err := h.cookies.AccessToken(c.Request); err == nil {
+			accessToken = cookieToken
+		}
+	}
+	if accessToken == "" {
+		return entity.TokenResponse{}, fmt.Errorf("%w: access token is required", service.ErrInvalidRequest)
+	}
+
+	claims, err := h.services.ParseExpiredToken(accessToken)
+	if err != nil {
+		return entity.TokenResponse{}, fmt.Errorf("%w: %w", service.ErrInvalidGrant, err)
+	}
+
+	// Ошибки хранилища остаются server_error, invalid_grant только для отклоненных токенов.
+	response, err := h.services.RefreshTokens(c, *claims, refreshToken, userAgent, clientIP, cnf)
+	if err != nil && problemFor(err) != problemInternal {
+		return entity.TokenResponse{}, fmt.Errorf("%w: %w", service.ErrInvalidGrant, err)
+	}
+	if err != nil {
+		return entity.TokenResponse{}, err
+	}
+
+	return response, nil
+}
+
+func oauthErrorCode(err error) string {
+	switch {
+	case errors.Is(err, service.ErrInvalidClient):
+		return "invalid_client"
+	case errors.Is(err, service.ErrUnsupportedResponseType):
+		return "unsupported_response_type"
+	case errors.Is(err, service.ErrInvalidGrant):
+		return "invalid_grant"
+	case errors.Is(err, service.ErrUnauthorizedClient):
+		return "unauthorized_client"
+	case errors.Is(err, service.ErrInvalidScope):
+		return "invalid_scope"
+	case errors.Is(err, service.ErrInvalidDPoPProof):
+		return "invalid_dpop_proof"
+	case errors.Is(err, service.ErrInvalidRequest), errors.Is(err, service.ErrInvalidRedirectURI):
+		return "invalid_request"
+	default:
+		return "server_error"
+	}
+}
+
+func oauthErrorStatus(err error) int {
+	switch oauthErrorCode(err) {
+	case "invalid_client":
+		return http.StatusUnauthorized
+	case "server_error":
+		return http.StatusInternalServerError
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+func redirectWithParams(c *gin.Context, redirectURI *url.URL, params map[string]string) {
+	location := *redirectURI
+	query := location.Query()
+	for key, value := range params {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+	location.RawQuery = query.Encode()
+
+	c.Redirect(http.StatusFound, location.String())
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/BabyJhon/medods-test-task/internal/entity"
+	"github.com/BabyJhon/medods-test-task/internal/i18n"
+	"github.com/BabyJhon/medods-test-task/internal/repo"
+	"github.com/BabyJhon/medods-test-task/internal/repo/memory"
+	"github.com/BabyJhon/medods-test-task/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// brokenOAuthRepo fails like an unavailable database.
+type brokenOAuthRepo struct {
+	repo.OAuth
+}
+
+func (r brokenOAuthRepo) GetClientByID(ctx context.Context, clientID string) (entity.OAuthClient, error) {
+	return entity.OAuthClient{}, errors.New("failed to connect to host=db user=postgres")
+}
+
+func TestTokenErrorsDontLeakDetails(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name   string
+		broken bool
+		status int
+		code   string
+	}{
+		{name: "unknown client", status: http.StatusUnauthorized, code: "invalid_client"},
+		{name: "storage error", broken: true, status: http.StatusInternalServerError, code: "server_error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repos := memory.NewRepository()
+			if tt.broken {
+				repos.OAuth = brokenOAuthRepo{repos.OAuth}
+			}
+			h := &Handler{services: service.NewService(repos, nil, i18n.Translator{})}
+
+			router := gin.New()
+			router.POST("/oauth/token", h.token)
+
+			form := url.Values{
+				"grant_type":    {service.GrantTypeClientCredentials},
+				"client_id":     {"missing"},
+				"client_secret": {"secret"},
+				"delivery":      {"json"},
+			}
+			req := httptest.NewRequest(http.MethodPost, "/oauth/token", strings.NewReader(form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tt.status {
+				t.Fatalf("got status %d, want %d: %s", w.Code, tt.status, w.Body.String())
+			}
+
+			var response OAuthError
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatal(err)
+			}
+			if response.Error != tt.code {
+				t.Fatalf("got error %q, want %q", response.Error, tt.code)
+			}
+			if response.ErrorDescription != oauthErrorDescriptions[tt.code] {
+				t.Fatalf("got description %q, want %q", response.ErrorDescription, oauthErrorDescriptions[tt.code])
+			}
+		})
+	}
+}
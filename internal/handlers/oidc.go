@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OpenIDConfiguration godoc
+// @Summary Метаданные OpenID Connect провайдера
+// @Description Документ discovery (OpenID Connect Discovery 1.0) с адресами эндпоинтов и поддерживаемыми параметрами.
+// @Tags oidc
+// @Produce json
+// @Success 200 {object} entity.OpenIDConfiguration "Метаданные провайдера"
+// @Router /.well-known/openid-configuration [get]
+func (h *Handler) openIDConfiguration(c *gin.Context) {
+	c.JSON(http.StatusOK, h.services.Discovery())
+}
+
+// JWKS godoc
+// @Summary Публичные ключи для проверки ID токенов
+// @Description Набор ключей в формате JWK Set (RFC 7517).
+// @Tags oidc
+// @Produce json
+// @Success 200 {object} jwk.Set "Набор публичных ключей"
+// @Router /.well-known/jwks.json [get]
+func (h *Handler) jwks(c *gin.Context) {
+	c.JSON(http.StatusOK, h.services.JWKS())
+}
+
+// UserInfo godoc
+// @Summary Информация о пользователе (OIDC userinfo)
+// @Description Возвращает claims пользователя по access токену из заголовка Authorization. Токен отозванной сессии не принимается.
+// @Tags oidc
+// @Security ApiKeyAuth
+// @Produce json
+// @Param Authorization header string true "Токен доступа в формате: Bearer <token>"
+// @Success 200 {object} entity.UserInfo "Claims пользователя"
+// @Failure 401 {object} Problem "Неавторизованный доступ: missing_token, invalid_token, token_expired, session_revoked"
+// @Router /userinfo [get]
+// @Router /userinfo [post]
+func (h *Handler) userInfo(c *gin.Context) {
+	tokenClaimes, ok := h.parseAuthorizationHeader(c)
+	if !ok {
+		return
+	}
+
+	userInfo, err := h.services.UserInfo(c, *tokenClaimes)
+	if err != nil {
+		c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+		newErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, userInfo)
+}
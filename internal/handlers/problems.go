@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/BabyJhon/medods-test-task/internal/service"
+)
+
+// problemTypePrefix makes the code of a problem type its RFC 7807 type URI.
+const problemTypePrefix = "urn:problem-type:"
+
+// problemType is an entry of the error catalog. Code is stable and meant for clients to
+// branch on; the title is the localized "problem.<code>" message and never includes
+// error details.
+type problemType struct {
+	Code   string
+	Status int
+}
+
+var (
+	problemInvalidRequest    = problemType{"invalid_request", http.StatusBadRequest}
+	problemMissingToken      = problemType{"missing_token", http.StatusUnauthorized}
+	problemInvalidToken      = problemType{"invalid_token", http.StatusUnauthorized}
+	problemTokenExpired      = problemType{"token_expired", http.StatusUnauthorized}
+	problemSessionRevoked    = problemType{"session_revoked", http.StatusUnauthorized}
+	problemTokensMismatch    = problemType{"tokens_mismatch", http.StatusUnauthorized}
+	problemUAMismatch        = problemType{"ua_mismatch", http.StatusUnauthorized}
+	problemRefreshReuse      = problemType{"refresh_reuse", http.StatusUnauthorized}
+	problemInvalidDPoPProof  = problemType{"invalid_dpop_proof", http.StatusUnauthorized}
+	problemInsufficientScope = problemType{"insufficient_scope", http.StatusForbidden}
+	problemInsufficientRole  = problemType{"insufficient_role", http.StatusForbidden}
+	problemCSRF              = problemType{"csrf_failed", http.StatusForbidden}
+	problemNotFound          = problemType{"not_found", http.StatusNotFound}
+	problemInternal          = problemType{"internal_error", http.StatusInternalServerError}
+)
+
+// Errors of the handlers themselves, mapped to the catalog like the service errors.
+var (
+	errInvalidRequest    = errors.New("invalid request")
+	errMissingToken      = errors.New("missing access token")
+	errInsufficientScope = errors.New("insufficient scope")
+	errInsufficientRole  = errors.New("insufficient role")
+	errCSRF              = errors.New("csrf check failed")
+)
+
+// paramError is an invalid request parameter. Its name is returned to the client, the
+// wrapped error is only logged.
+type paramError struct {
+	name string
+	err  error
+}
+
+func invalidParam(name string, err error) error {
+	return &paramError{name: name, err: err}
+}
+
+func (e *paramError) Error() string {
+	return e.name + ": " + e.err.Error()
+}
+
+func (e *paramError) Unwrap() []error {
+	return []error{errInvalidRequest, e.err}
+}
+
+// problemFor finds the catalog entry of the error. Errors without one are internal.
+func problemFor(err error) problemType {
+	switch {
+	case errors.Is(err, errInvalidRequest), errors.Is(err, service.ErrInvalidRequest):
+		return problemInvalidRequest
+	case errors.Is(err, errMissingToken):
+		return problemMissingToken
+	case errors.Is(err, service.ErrTokenExpired):
+		return problemTokenExpired
+	case errors.Is(err, service.ErrSessionRevoked):
+		return problemSessionRevoked
+	case errors.Is(err, service.ErrTokensMismatch):
+		return problemTokensMismatch
+	case errors.Is(err, service.ErrUserAgentChanged):
+		return problemUAMismatch
+	case errors.Is(err, service.ErrRefreshReuse):
+		return problemRefreshReuse
+	case errors.Is(err, service.ErrInvalidDPoPProof):
+		return problemInvalidDPoPProof
+	case errors.Is(err, service.ErrInvalidToken), errors.Is(err, service.ErrCertificateMismatch), errors.Is(err, service.ErrDPoPKeyMismatch):
+		return problemInvalidToken
+	case errors.Is(err, errInsufficientScope):
+		return problemInsufficientScope
+	case errors.Is(err, errInsufficientRole):
+		return problemInsufficientRole
+	case errors.Is(err, errCSRF):
+		return problemCSRF
+	case errors.Is(err, service.ErrNotFound):
+		return problemNotFound
+	default:
+		return problemInternal
+	}
+}
@@ -1,15 +1,88 @@
 package handlers
 
 import (
+	"errors"
+	"net/http"
+
+	"github.com/BabyJhon/medods-test-task/internal/logging"
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 )
 
-type Error struct {
-	Message string `json:"message"`
+const problemContentType = "application/problem+json"
+
+// Problem is an RFC 7807 problem details response. Code repeats the catalog code of the
+// type URI for clients that don't parse it; Param names the invalid request parameter.
+// Title and Detail are in the language negotiated by Accept-Language.
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Code     string `json:"code"`
+	Param    string `json:"param,omitempty"`
+	Instance string `json:"instance,omitempty"`
+}
+
+// newErrorResponse renders the catalog entry of err. The error itself may carry storage
+// or library details, so it is only logged.
+func newErrorResponse(c *gin.Context, err error) {
+	problem := problemFor(err)
+
+	entry := logging.FromContext(c).WithFields(logrus.Fields{"code": problem.Code, "path": c.Request.URL.Path})
+	if problem.Status >= http.StatusInternalServerError {
+		entry.Error(err.Error())
+	} else {
+		entry.Warn(err.Error())
+	}
+
+	messages := translator(c)
+	response := Problem{
+		Type:     problemTypePrefix + problem.Code,
+		Title:    messages.Message("problem." + problem.Code),
+		Status:   problem.Status,
+		Code:     problem.Code,
+		Instance: c.Request.URL.Path,
+	}
+	var param *paramError
+	if errors.As(err, &param) {
+		response.Param = param.name
+		response.Detail = messages.Message("problem.invalid_param", param.name)
+	}
+
+	c.Header("Content-Type", problemContentType)
+	if lang := messages.Language(); lang != "" {
+		c.Header("Content-Language", lang)
+	}
+	c.AbortWithStatusJSON(problem.Status, response)
+}
+
+type OAuthError struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+// oauthErrorDescriptions are the only descriptions OAuth clients get. Errors may wrap
+// storage or library details, so they are only logged, and server_error has none.
+var oauthErrorDescriptions = map[string]string{
+	"invalid_request":           "The request is missing a parameter or is malformed",
+	"invalid_client":            "Client authentication failed",
+	"invalid_grant":             "The authorization code or refresh token is invalid, expired or revoked",
+	"unauthorized_client":       "The client is not allowed to use this grant type",
+	"unsupported_grant_type":    "The grant type is not supported",
+	"unsupported_response_type": "The response type is not supported",
+	"invalid_scope":             "The requested scope is invalid or not allowed for the client",
+	"invalid_dpop_proof":        "The DPoP proof is invalid",
 }
 
-func newErrorResponse(c *gin.Context, statucCode int, message string) {
-	logrus.Error(message)
-	c.AbortWithStatusJSON(statucCode, Error{message})
+// newOAuthErrorResponse logs err like newErrorResponse and answers with the fixed
+// description of the code.
+func newOAuthErrorResponse(c *gin.Context, statusCode int, code string, err error) {
+	entry := logging.FromContext(c).WithFields(logrus.Fields{"code": code, "path": c.Request.URL.Path})
+	if statusCode >= http.StatusInternalServerError {
+		entry.Error(err.Error())
+	} else {
+		entry.Warn(err.Error())
+	}
+	c.AbortWithStatusJSON(statusCode, OAuthError{code, oauthErrorDescriptions[code]})
 }
@@ -1,30 +1,135 @@
 package handlers
 
 import (
-	_ "github.com/BabyJhon/medods-test-task/docs" // путь к docs
+	"net/http"
+
+	docsv1 "github.com/BabyJhon/medods-test-task/docs/v1"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 
+	"github.com/BabyJhon/medods-test-task/internal/i18n"
 	"github.com/BabyJhon/medods-test-task/internal/service"
 	"github.com/gin-gonic/gin"
 )
 
 type Handler struct {
 	services *service.Service
+	cookies  *CookiePolicy
+	csrf     *CSRFGuard
+	cors     CORSRoutes
+	messages *i18n.Localizer
+	legacy   LegacyAPI
+	ready    Readiness
 }
 
-func NewHandler(services *service.Service) *Handler {
-	return &Handler{services: services}
+func NewHandler(services *service.Service, cookies *CookiePolicy, csrf *CSRFGuard, cors CORSRoutes, messages *i18n.Localizer, legacy LegacyAPI, ready Readiness) *Handler {
+	return &Handler{
+		services: services,
+		cookies:  cookies,
+		csrf:     csrf,
+		cors:     cors,
+		messages: messages,
+		legacy:   legacy,
+		ready:    ready,
+	}
 }
 
-func (h *Handler) InitRoutes() *gin.Engine {
+// newRouter returns an engine with the middleware shared by both listeners.
+func (h *Handler) newRouter() *gin.Engine {
 	router := gin.New()
+	// Обработчики передают gin.Context в сервисы как context.Context, а request ID
+	// хранится в контексте запроса.
+	router.ContextWithFallback = true
+	router.Use(requestID, h.localize, accessLog, recovery)
+	h.initHealthRoutes(router)
+	return router
+}
+
+func (h *Handler) InitRoutes() *gin.Engine {
+	router := h.newRouter()
 
-	router.GET("/auth", h.auth)
-	router.GET("/user", h.user)
-	router.POST("/revoke", h.revoke)
-	router.POST("/refresh", h.refresh)
-	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	v1 := router.Group(APIV1Path, apiBase(APIV1Path))
+	h.initAPIRoutes(v1)
+	h.initDiscoveryRoutes(v1)
+	v1.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler, ginSwagger.InstanceName(docsv1.SwaggerInfov1.InstanceName())))
+
+	// Документы discovery остаются в корне: OIDC ищет их по адресу issuer.
+	h.initDiscoveryRoutes(router.Group(""))
+
+	legacy := router.Group("", apiBase(""), h.legacy.deprecated(APIV1Path))
+	h.initAPIRoutes(legacy)
+	legacy.GET("/swagger/*any", func(c *gin.Context) {
+		c.Redirect(http.StatusMovedPermanently, APIV1Path+"/swagger"+c.Param("any"))
+	})
 
 	return router
 }
+
+// initAPIRoutes registers the public API of a version under the group.
+func (h *Handler) initAPIRoutes(api *gin.RouterGroup) {
+	auth := api.Group("", h.cors.Auth.handler())
+	{
+		auth.GET("/auth", h.auth)
+		auth.GET("/user", h.user)
+		auth.POST("/revoke", h.revoke)
+		auth.POST("/refresh", h.requireCSRF(), h.refresh)
+		auth.GET("/userinfo", h.userInfo)
+		auth.POST("/userinfo", h.userInfo)
+	}
+	handlePreflight(auth, "/auth", "/user", "/revoke", "/refresh", "/userinfo")
+
+	oauth := api.Group("/oauth")
+	{
+		oauth.GET("/authorize", h.authorize)
+		oauth.POST("/introspect", h.introspect)
+	}
+	// Авторизация открывается навигацией, а интроспекция вызывается только сервисами,
+	// поэтому CORS нужен лишь эндпоинту токенов.
+	token := oauth.Group("/token", h.cors.OAuth.handler())
+	{
+		token.POST("", h.token)
+	}
+	handlePreflight(token, "")
+}
+
+func (h *Handler) initDiscoveryRoutes(api *gin.RouterGroup) {
+	wellKnown := api.Group("/.well-known", h.cors.Discovery.handler())
+	{
+		wellKnown.GET("/openid-configuration", h.openIDConfiguration)
+		wellKnown.GET("/jwks.json", h.jwks)
+	}
+	handlePreflight(wellKnown, "/openid-configuration", "/jwks.json")
+}
+
+// InitAdminRoutes builds the admin API. It is served on a separate listener and is not
+// reachable through the public router.
+func (h *Handler) InitAdminRoutes() *gin.Engine {
+	router := h.newRouter()
+
+	h.initAdminAPIRoutes(router.Group(APIV1Path, apiBase(APIV1Path)))
+	h.initAdminAPIRoutes(router.Group("", apiBase(""), h.legacy.deprecated(APIV1Path)))
+
+	h.initConsoleRoutes(router)
+
+	return router
+}
+
+func (h *Handler) initAdminAPIRoutes(api *gin.RouterGroup) {
+	admin := api.Group("/admin", h.authenticate, requireRoles(service.RoleAdmin), requireScopes(service.ScopeAdmin))
+	{
+		admin.POST("/clients", h.createClient)
+		admin.POST("/clients/:id/secret", h.rotateClientSecret)
+		admin.POST("/clients/:id/disable", h.disableClient)
+
+		admin.GET("/roles", h.listRoles)
+		admin.PUT("/roles/:name", h.upsertRole)
+		admin.GET("/users/:id/roles", h.getUserRoles)
+		admin.POST("/users/:id/roles", h.assignRole)
+		admin.DELETE("/users/:id/roles/:role", h.removeRole)
+
+		admin.GET("/sessions", h.searchSessions)
+		admin.POST("/sessions/revoke", h.revokeSessions)
+		admin.GET("/users/:id/events", h.getUserEvents)
+		admin.POST("/users/:id/logout", h.logoutUser)
+	}
+}
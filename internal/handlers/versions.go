@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	APIV1Path = "/api/v1"
+
+	apiBaseCtxKey = "api_base"
+)
+
+// LegacyAPI describes the retirement of the unversioned routes, which are kept as aliases
+// of v1 so existing clients keep working while they move to versioned paths.
+type LegacyAPI struct {
+	// DeprecatedAt is sent in the Deprecation header (RFC 9745).
+	DeprecatedAt time.Time
+	// Sunset is sent in the Sunset header (RFC 8594), the date after which the aliases may
+	// stop working. Zero omits the header.
+	Sunset time.Time
+}
+
+// deprecated marks responses of the legacy aliases and links them to the same route of
+// the successor version.
+func (l LegacyAPI) deprecated(successor string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Deprecation", fmt.Sprintf("@%d", l.DeprecatedAt.Unix()))
+		if !l.Sunset.IsZero() {
+			c.Header("Sunset", l.Sunset.UTC().Format(http.TimeFormat))
+		}
+		c.Header("Link", fmt.Sprintf(`<%s%s>; rel="successor-version"`, successor, c.Request.URL.Path))
+		c.Next()
+	}
+}
+
+// apiBase records the root of the API version serving the request, so that paths such as
+// the refresh cookie path can be resolved against it.
+func apiBase(base string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(apiBaseCtxKey, base)
+		c.Next()
+	}
+}
@@ -0,0 +1,143 @@
+// Package i18n translates the messages the service shows to people: problem titles of
+// the API and webhook event descriptions. Bundles are JSON files embedded in the binary,
+// one per language, mapping message keys to fmt templates.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+const (
+	English = "en"
+	Russian = "ru"
+)
+
+//go:embed locales/*.json
+var locales embed.FS
+
+type Localizer struct {
+	bundles  map[string]map[string]string
+	fallback string
+	tags     []language.Tag
+	matcher  language.Matcher
+}
+
+// New loads the embedded bundles. fallback is used when a client accepts none of the
+// supported languages. Every bundle must have the same keys, so a message missing from
+// one of them is caught at startup rather than shown as its key.
+func New(fallback string) (*Localizer, error) {
+	files, err := locales.ReadDir("locales")
+	if err != nil {
+		return nil, err
+	}
+
+	l := &Localizer{bundles: make(map[string]map[string]string)}
+	for _, file := range files {
+		data, err := locales.ReadFile(path.Join("locales", file.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var bundle map[string]string
+		if err := json.Unmarshal(data, &bundle); err != nil {
+			return nil, fmt.Errorf("bundle %s: %w", file.Name(), err)
+		}
+		l.bundles[strings.TrimSuffix(file.Name(), ".json")] = bundle
+	}
+
+	if _, ok := l.bundles[fallback]; !ok {
+		return nil, fmt.Errorf("unsupported fallback language %q", fallback)
+	}
+	l.fallback = fallback
+
+	for lang, bundle := range l.bundles {
+		for key := range l.bundles[fallback] {
+			if _, ok := bundle[key]; !ok {
+				return nil, fmt.Errorf("bundle %s has no message %q", lang, key)
+			}
+		}
+		for key := range bundle {
+			if _, ok := l.bundles[fallback][key]; !ok {
+				return nil, fmt.Errorf("bundle %s has unknown message %q", lang, key)
+			}
+		}
+	}
+
+	// Первый тег матчера используется, когда ни один язык не подошел.
+	l.tags = []language.Tag{language.Make(fallback)}
+	for _, lang := range l.Languages() {
+		if lang != fallback {
+			l.tags = append(l.tags, language.Make(lang))
+		}
+	}
+	l.matcher = language.NewMatcher(l.tags)
+
+	return l, nil
+}
+
+// Languages returns the supported languages.
+func (l *Localizer) Languages() []string {
+	languages := make([]string, 0, len(l.bundles))
+	for lang := range l.bundles {
+		languages = append(languages, lang)
+	}
+	slices.Sort(languages)
+	return languages
+}
+
+// Match returns the supported language that best fits an Accept-Language header, or the
+// fallback language.
+func (l *Localizer) Match(acceptLanguage string) string {
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return l.fallback
+	}
+
+	_, index, confidence := l.matcher.Match(tags...)
+	if confidence == language.No {
+		return l.fallback
+	}
+	base, _ := l.tags[index].Base()
+	return base.String()
+}
+
+// Translator returns a Translator for the language, or for the fallback language if it
+// isn't supported.
+func (l *Localizer) Translator(lang string) Translator {
+	if _, ok := l.bundles[lang]; !ok {
+		lang = l.fallback
+	}
+	return Translator{localizer: l, lang: lang}
+}
+
+// Translator formats messages in one language. The zero value returns message keys.
+type Translator struct {
+	localizer *Localizer
+	lang      string
+}
+
+func (t Translator) Language() string {
+	return t.lang
+}
+
+// Message formats the message with args, returning the key itself if there is no such
+// message.
+func (t Translator) Message(key string, args ...any) string {
+	if t.localizer == nil {
+		return key
+	}
+	template, ok := t.localizer.bundles[t.lang][key]
+	if !ok {
+		return key
+	}
+	if len(args) == 0 {
+		return template
+	}
+	return fmt.Sprintf(template, args...)
+}
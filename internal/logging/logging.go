@@ -0,0 +1,76 @@
+// Package logging configures the standard logrus logger and carries request-scoped fields,
+// such as the request ID, in context.Context, so that entries written deep in the service
+// layer can be correlated with the request that caused them. Entries pass through a
+// redacting formatter before they are written, see Policy.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"maps"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+
+	RequestIDField = "request_id"
+	UserIDField    = "user_id"
+)
+
+type Config struct {
+	// Level defaults to info.
+	Level string
+	// Format is FormatJSON, the default, or FormatText.
+	Format    string
+	Redaction Policy
+}
+
+// Setup configures the standard logger. Entries logged before it are written unredacted.
+func Setup(cfg Config) error {
+	level := logrus.InfoLevel
+	if cfg.Level != "" {
+		var err error
+		if level, err = logrus.ParseLevel(cfg.Level); err != nil {
+			return err
+		}
+	}
+
+	var formatter logrus.Formatter
+	switch cfg.Format {
+	case "", FormatJSON:
+		formatter = new(logrus.JSONFormatter)
+	case FormatText:
+		formatter = new(logrus.TextFormatter)
+	default:
+		return fmt.Errorf("unknown log format %q, want %s or %s", cfg.Format, FormatJSON, FormatText)
+	}
+
+	logrus.SetLevel(level)
+	logrus.SetFormatter(&redactingFormatter{next: formatter, policy: cfg.Redaction})
+	return nil
+}
+
+type fieldsKey struct{}
+
+// WithFields returns a copy of ctx whose entries carry the fields on top of the fields
+// already in ctx.
+func WithFields(ctx context.Context, fields logrus.Fields) context.Context {
+	merged := make(logrus.Fields, len(fields))
+	if parent, ok := ctx.Value(fieldsKey{}).(logrus.Fields); ok {
+		maps.Copy(merged, parent)
+	}
+	maps.Copy(merged, fields)
+	return context.WithValue(ctx, fieldsKey{}, merged)
+}
+
+// FromContext returns an entry of the standard logger with the fields of ctx.
+func FromContext(ctx context.Context) *logrus.Entry {
+	entry := logrus.NewEntry(logrus.StandardLogger())
+	if fields, ok := ctx.Value(fieldsKey{}).(logrus.Fields); ok {
+		entry = entry.WithFields(fields)
+	}
+	return entry.WithContext(ctx)
+}
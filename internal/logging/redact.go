@@ -0,0 +1,144 @@
+package logging
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	redacted = "[REDACTED]"
+
+	defaultIPv4Prefix = 24
+	defaultIPv6Prefix = 48
+)
+
+var (
+	jwtPattern = regexp.MustCompile(`eyJ[A-Za-z0-9_-]*\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+	// Пользователи и сессии идентифицируются UUID, в тексте их не различить, поэтому
+	// хэшируются все.
+	uuidPattern = regexp.MustCompile(`(?i)[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}`)
+	// Refresh токены, CSRF токены и секреты клиентов это длинные base64url строки.
+	secretPattern = regexp.MustCompile(`[A-Za-z0-9_-]{32,}`)
+	ipv4Pattern   = regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b`)
+	ipv6Pattern   = regexp.MustCompile(`[0-9A-Fa-f]{0,4}(?::[0-9A-Fa-f]{0,4}){2,7}`)
+)
+
+// Policy describes how personal data and credentials are masked in log entries. Tokens
+// and secrets are replaced, user IDs and user agents are hashed, so entries of the same
+// user can still be grouped, and IPs are truncated to their network prefix.
+type Policy struct {
+	// Disabled writes entries as they are, only for local debugging.
+	Disabled bool
+	// HashKey keys the hashes, so they can't be matched by hashing a known user ID. Without
+	// it plain SHA-256 is used.
+	HashKey []byte
+	// IPv4Prefix and IPv6Prefix are the prefix lengths IPs are truncated to, 24 and 48 by
+	// default.
+	IPv4Prefix int
+	IPv6Prefix int
+}
+
+// redactField masks a field value by its key or, for unknown keys, by its content.
+func (p Policy) redactField(key string, value any) any {
+	key = strings.ToLower(key)
+	switch {
+	case key == RequestIDField:
+		return value
+	case secretField(key):
+		return redacted
+	case key == UserIDField || key == "sub" || key == "user_agent":
+		return p.hash(fmt.Sprint(value))
+	}
+
+	switch v := value.(type) {
+	case string:
+		return p.redactText(v)
+	case error:
+		return p.redactText(v.Error())
+	case fmt.Stringer:
+		return p.redactText(v.String())
+	default:
+		return value
+	}
+}
+
+func secretField(key string) bool {
+	for _, word := range []string{"token", "secret", "password", "authorization", "cookie", "dpop"} {
+		if strings.Contains(key, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactText masks tokens, UUIDs and IPs found in free text such as messages and error
+// strings. UUIDs go before secrets: they would match the secret pattern too.
+func (p Policy) redactText(text string) string {
+	text = jwtPattern.ReplaceAllString(text, redacted)
+	text = uuidPattern.ReplaceAllStringFunc(text, p.hash)
+	text = secretPattern.ReplaceAllString(text, redacted)
+	text = ipv4Pattern.ReplaceAllStringFunc(text, p.truncateIP)
+	return ipv6Pattern.ReplaceAllStringFunc(text, p.truncateIP)
+}
+
+func (p Policy) hash(value string) string {
+	var sum []byte
+	if len(p.HashKey) > 0 {
+		mac := hmac.New(sha256.New, p.HashKey)
+		mac.Write([]byte(value))
+		sum = mac.Sum(nil)
+	} else {
+		digest := sha256.Sum256([]byte(value))
+		sum = digest[:]
+	}
+	return hex.EncodeToString(sum[:8])
+}
+
+// truncateIP returns the network of the IP, or value itself if it isn't an IP.
+func (p Policy) truncateIP(value string) string {
+	ip := net.ParseIP(value)
+	if ip == nil {
+		return value
+	}
+
+	if ipv4 := ip.To4(); ipv4 != nil {
+		prefix := p.IPv4Prefix
+		if prefix == 0 {
+			prefix = defaultIPv4Prefix
+		}
+		return ipv4.Mask(net.CIDRMask(prefix, 32)).String()
+	}
+
+	prefix := p.IPv6Prefix
+	if prefix == 0 {
+		prefix = defaultIPv6Prefix
+	}
+	return ip.Mask(net.CIDRMask(prefix, 128)).String()
+}
+
+// redactingFormatter applies the policy to a copy of each entry and passes it on.
+type redactingFormatter struct {
+	next   logrus.Formatter
+	policy Policy
+}
+
+func (f *redactingFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	if f.policy.Disabled {
+		return f.next.Format(entry)
+	}
+
+	clean := *entry
+	clean.Message = f.policy.redactText(entry.Message)
+	clean.Data = make(logrus.Fields, len(entry.Data))
+	for key, value := range entry.Data {
+		clean.Data[key] = f.policy.redactField(key, value)
+	}
+	return f.next.Format(&clean)
+}
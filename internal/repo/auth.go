@@ -4,14 +4,20 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/BabyJhon/medods-test-task/internal/entity"
 	"github.com/BabyJhon/medods-test-task/pkg/postgres"
 	"github.com/gofrs/uuid"
-	"github.com/jackc/pgx"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+const (
+	sessionColumns = "id, user_id, refresh_hash, user_agent, ip, created_at, expires_at, is_revoked, scope"
+)
+
 type AuthRepo struct {
 	db *pgxpool.Pool
 }
@@ -24,9 +30,9 @@ func NewAuthRepo(db *pgxpool.Pool) *AuthRepo {
 
 func (r *AuthRepo) CreateSession(ctx context.Context, session entity.Session) (uuid.UUID, error) {
 	var id uuid.UUID
-	query := fmt.Sprintf("INSERT INTO %s (id,  user_id,  refresh_hash, user_agent, ip, created_at,  expires_at, is_revoked) values ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id", postgres.SessionTable)
+	query := fmt.Sprintf("INSERT INTO %s (id,  user_id,  refresh_hash, user_agent, ip, created_at,  expires_at, is_revoked, scope) values ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id", postgres.SessionTable)
 
-	row := r.db.QueryRow(ctx, query, session.ID, session.UserId, session.RefreshHash, session.UserAgent, session.IP, session.CreatedAt, session.ExpiresAt, session.IsRevorked)
+	row := r.db.QueryRow(ctx, query, session.ID, session.UserId, session.RefreshHash, session.UserAgent, session.IP, session.CreatedAt, session.ExpiresAt, session.IsRevorked, session.Scope)
 	if err := row.Scan(&id); err != nil {
 		return id, err
 	}
@@ -37,12 +43,12 @@ func (r *AuthRepo) CreateSession(ctx context.Context, session entity.Session) (u
 func (r *AuthRepo) GetSessionByID(ctx context.Context, sessionID uuid.UUID) (entity.Session, error) {
 	var session entity.Session
 
-	query := fmt.Sprintf("SELECT * FROM %s WHERE id = $1", postgres.SessionTable)
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE id = $1", sessionColumns, postgres.SessionTable)
 
 	row := r.db.QueryRow(ctx, query, sessionID)
-	if err := row.Scan(&session.ID, &session.UserId, &session.RefreshHash, &session.UserAgent, &session.IP, &session.CreatedAt, &session.ExpiresAt, &session.IsRevorked); err != nil {
+	if err := row.Scan(&session.ID, &session.UserId, &session.RefreshHash, &session.UserAgent, &session.IP, &session.CreatedAt, &session.ExpiresAt, &session.IsRevorked, &session.Scope); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return entity.Session{}, errors.New("session not found")
+			return entity.Session{}, fmt.Errorf("session %w", ErrNotFound)
 		}
 		return entity.Session{}, err
 	}
@@ -51,23 +57,22 @@ func (r *AuthRepo) GetSessionByID(ctx context.Context, sessionID uuid.UUID) (ent
 }
 
 func (r *AuthRepo) RevokeToken(ctx context.Context, sessionID uuid.UUID) error {
-	revokeToken := fmt.Sprintf("UPDATE %s SET is_revoked = true WHERE id = $1", postgres.SessionTable)
+	revokeToken := fmt.Sprintf("UPDATE %s SET is_revoked = true WHERE id = $1 AND is_revoked = false", postgres.SessionTable)
 	result, err := r.db.Exec(ctx, revokeToken, sessionID)
 	if err != nil {
 		return err
 	}
 
 	if result.RowsAffected() == 0 {
-		return errors.New("session not found or already revoked")
+		return fmt.Errorf("session %w or already revoked", ErrNotFound)
 	}
 
 	return nil
 }
 
-
 func (r *AuthRepo) GetAllSessions(ctx context.Context) ([]*entity.Session, error) {
 	var sessions []*entity.Session
-	query := fmt.Sprintf("SELECT * FROM %s WHERE is_revoked = false", postgres.SessionTable)
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE is_revoked = false", sessionColumns, postgres.SessionTable)
 
 	rows, err := r.db.Query(ctx, query)
 	if err != nil {
@@ -77,7 +82,7 @@ func (r *AuthRepo) GetAllSessions(ctx context.Context) ([]*entity.Session, error
 
 	for rows.Next() {
 		var session entity.Session
-		if err := rows.Scan(&session.ID, &session.UserId, &session.RefreshHash, &session.UserAgent, &session.IP, &session.CreatedAt, &session.ExpiresAt, &session.IsRevorked); err != nil {
+		if err := rows.Scan(&session.ID, &session.UserId, &session.RefreshHash, &session.UserAgent, &session.IP, &session.CreatedAt, &session.ExpiresAt, &session.IsRevorked, &session.Scope); err != nil {
 			return nil, err
 		}
 		sessions = append(sessions, &session)
@@ -93,7 +98,7 @@ func (r *AuthRepo) RefreshTokens(ctx context.Context, oldSession, newSession ent
 		return id, err
 	}
 
-	revokeToken := fmt.Sprintf("UPDATE %s SET is_revoked = true WHERE id = $1", postgres.SessionTable)
+	revokeToken := fmt.Sprintf("UPDATE %s SET is_revoked = true WHERE id = $1 AND is_revoked = false", postgres.SessionTable)
 	result, err := tx.Exec(ctx, revokeToken, oldSession.ID)
 	if err != nil {
 		tx.Rollback(ctx)
@@ -102,12 +107,12 @@ func (r *AuthRepo) RefreshTokens(ctx context.Context, oldSession, newSession ent
 
 	if result.RowsAffected() == 0 {
 		tx.Rollback(ctx)
-		return id, errors.New("session not found or already revoked")
+		return id, fmt.Errorf("session %w or already revoked", ErrNotFound)
 	}
 
-	query := fmt.Sprintf("INSERT INTO %s (id,  user_id,  refresh_hash, user_agent, ip, created_at,  expires_at, is_revoked) values ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id", postgres.SessionTable)
+	query := fmt.Sprintf("INSERT INTO %s (id,  user_id,  refresh_hash, user_agent, ip, created_at,  expires_at, is_revoked, scope) values ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id", postgres.SessionTable)
 
-	row := tx.QueryRow(ctx, query, newSession.ID, newSession.UserId, newSession.RefreshHash, newSession.UserAgent, newSession.IP, newSession.CreatedAt, newSession.ExpiresAt, newSession.IsRevorked)
+	row := tx.QueryRow(ctx, query, newSession.ID, newSession.UserId, newSession.RefreshHash, newSession.UserAgent, newSession.IP, newSession.CreatedAt, newSession.ExpiresAt, newSession.IsRevorked, newSession.Scope)
 	if err := row.Scan(&id); err != nil {
 		tx.Rollback(ctx)
 		return id, err
@@ -115,3 +120,125 @@ func (r *AuthRepo) RefreshTokens(ctx context.Context, oldSession, newSession ent
 
 	return id, tx.Commit(ctx)
 }
+
+func (r *AuthRepo) GetSessionsByUser(ctx context.Context, userID uuid.UUID) ([]entity.Session, error) {
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE user_id = $1 ORDER BY created_at DESC", sessionColumns, postgres.SessionTable)
+
+	return r.querySessions(ctx, query, userID)
+}
+
+func (r *AuthRepo) FindSessions(ctx context.Context, filter entity.SessionFilter) ([]entity.Session, error) {
+	var (
+		conditions []string
+		args       []any
+	)
+	where := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.UserID != uuid.Nil {
+		where("user_id = $%d", filter.UserID)
+	}
+	if filter.IP != nil {
+		where("ip = $%d", filter.IP)
+	}
+	if filter.UserAgent != "" {
+		where("strpos(lower(user_agent), lower($%d)) > 0", filter.UserAgent)
+	}
+	if !filter.CreatedFrom.IsZero() {
+		where("created_at >= $%d", filter.CreatedFrom)
+	}
+	if !filter.CreatedTo.IsZero() {
+		where("created_at < $%d", filter.CreatedTo)
+	}
+	if filter.ActiveOnly {
+		where("is_revoked = false AND expires_at > $%d", time.Now())
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM %s", sessionColumns, postgres.SessionTable)
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY created_at DESC"
+	if filter.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", filter.Limit)
+	}
+
+	return r.querySessions(ctx, query, args...)
+}
+
+func (r *AuthRepo) RevokeSessions(ctx context.Context, ids []uuid.UUID) ([]entity.Session, error) {
+	stringIDs := make([]string, 0, len(ids))
+	for _, id := range ids {
+		stringIDs = append(stringIDs, id.String())
+	}
+
+	query := fmt.Sprintf("UPDATE %s SET is_revoked = true WHERE id = ANY($1::uuid[]) AND is_revoked = false RETURNING %s", postgres.SessionTable, sessionColumns)
+
+	return r.querySessions(ctx, query, stringIDs)
+}
+
+func (r *AuthRepo) querySessions(ctx context.Context, query string, args ...any) ([]entity.Session, error) {
+	sessions := []entity.Session{}
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var session entity.Session
+		if err := rows.Scan(&session.ID, &session.UserId, &session.RefreshHash, &session.UserAgent, &session.IP, &session.CreatedAt, &session.ExpiresAt, &session.IsRevorked, &session.Scope); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+
+	return sessions, rows.Err()
+}
+
+func (r *AuthRepo) RevokeUserSessions(ctx context.Context, userID uuid.UUID) (int64, error) {
+	query := fmt.Sprintf("UPDATE %s SET is_revoked = true WHERE user_id = $1 AND is_revoked = false", postgres.SessionTable)
+	result, err := r.db.Exec(ctx, query, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected(), nil
+}
+
+func (r *AuthRepo) DeleteExpiredSessions(ctx context.Context, before time.Time) (int64, error) {
+	query := fmt.Sprintf("DELETE FROM %s WHERE expires_at < $1", postgres.SessionTable)
+	result, err := r.db.Exec(ctx, query, before)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected(), nil
+}
+
+func (r *AuthRepo) ListUsers(ctx context.Context, limit int) ([]entity.UserSummary, error) {
+	users := []entity.UserSummary{}
+	query := fmt.Sprintf("SELECT user_id, COUNT(*), COUNT(*) FILTER (WHERE is_revoked = false AND expires_at > $1), MAX(created_at) FROM %s GROUP BY user_id ORDER BY MAX(created_at) DESC", postgres.SessionTable)
+	if limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", limit)
+	}
+
+	rows, err := r.db.Query(ctx, query, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var user entity.UserSummary
+		if err := rows.Scan(&user.UserID, &user.Sessions, &user.ActiveSessions, &user.LastLoginAt); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
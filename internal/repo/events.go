@@ -0,0 +1,60 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/BabyJhon/medods-test-task/internal/entity"
+	"github.com/BabyJhon/medods-test-task/pkg/postgres"
+	"github.com/gofrs/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type EventsRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewEventsRepo(db *pgxpool.Pool) *EventsRepo {
+	return &EventsRepo{
+		db: db,
+	}
+}
+
+func (r *EventsRepo) CreateEvent(ctx context.Context, event entity.SecurityEvent) error {
+	query := fmt.Sprintf("INSERT INTO %s (id, user_id, session_id, type, ip, user_agent, details, created_at) values ($1, $2, $3, $4, $5, $6, $7, $8)", postgres.SecurityEventTable)
+
+	// Events created outside of a request have no IP.
+	var ip any
+	if event.IP != nil {
+		ip = event.IP
+	}
+
+	_, err := r.db.Exec(ctx, query, event.ID, event.UserID, event.SessionID, event.Type, ip, event.UserAgent, event.Details, event.CreatedAt)
+	return err
+}
+
+func (r *EventsRepo) GetUserEvents(ctx context.Context, userID uuid.UUID, limit int) ([]entity.SecurityEvent, error) {
+	events := []entity.SecurityEvent{}
+	query := fmt.Sprintf("SELECT id, user_id, session_id, type, COALESCE(host(ip), ''), user_agent, details, created_at FROM %s WHERE user_id = $1 ORDER BY created_at DESC LIMIT $2", postgres.SecurityEventTable)
+
+	rows, err := r.db.Query(ctx, query, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			event entity.SecurityEvent
+			ip    string
+		)
+		if err := rows.Scan(&event.ID, &event.UserID, &event.SessionID, &event.Type, &ip, &event.UserAgent, &event.Details, &event.CreatedAt); err != nil {
+			return nil, err
+		}
+		event.IP = net.ParseIP(ip)
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
@@ -0,0 +1,221 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BabyJhon/medods-test-task/internal/entity"
+	"github.com/BabyJhon/medods-test-task/internal/repo"
+	"github.com/gofrs/uuid"
+)
+
+type AuthRepo struct {
+	store *store
+}
+
+func (r *AuthRepo) CreateSession(ctx context.Context, session entity.Session) (uuid.UUID, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, exists := r.store.sessions[session.ID]; exists {
+		return uuid.Nil, errors.New("session already exists")
+	}
+	r.store.sessions[session.ID] = cloneSession(session)
+
+	return session.ID, nil
+}
+
+func (r *AuthRepo) GetSessionByID(ctx context.Context, sessionID uuid.UUID) (entity.Session, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	session, ok := r.store.sessions[sessionID]
+	if !ok {
+		return entity.Session{}, fmt.Errorf("session %w", repo.ErrNotFound)
+	}
+
+	return cloneSession(session), nil
+}
+
+func (r *AuthRepo) RevokeToken(ctx context.Context, sessionID uuid.UUID) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	return r.revoke(sessionID)
+}
+
+func (r *AuthRepo) GetAllSessions(ctx context.Context) ([]*entity.Session, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var sessions []*entity.Session
+	for _, session := range r.store.sessions {
+		if !session.IsRevorked {
+			session := cloneSession(session)
+			sessions = append(sessions, &session)
+		}
+	}
+
+	return sessions, nil
+}
+
+func (r *AuthRepo) RefreshTokens(ctx context.Context, oldSession, newSession entity.Session) (uuid.UUID, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	// Все проверки выполняются до изменений, чтобы при ошибке ничего не менялось, как при rollback.
+	if _, exists := r.store.sessions[newSession.ID]; exists {
+		return uuid.Nil, errors.New("session already exists")
+	}
+	if err := r.revoke(oldSession.ID); err != nil {
+		return uuid.Nil, err
+	}
+	r.store.sessions[newSession.ID] = cloneSession(newSession)
+
+	return newSession.ID, nil
+}
+
+func (r *AuthRepo) GetSessionsByUser(ctx context.Context, userID uuid.UUID) ([]entity.Session, error) {
+	return r.FindSessions(ctx, entity.SessionFilter{UserID: userID})
+}
+
+func (r *AuthRepo) FindSessions(ctx context.Context, filter entity.SessionFilter) ([]entity.Session, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	now := time.Now()
+	userAgent := strings.ToLower(filter.UserAgent)
+
+	sessions := []entity.Session{}
+	for _, session := range r.store.sessions {
+		switch {
+		case filter.UserID != uuid.Nil && session.UserId != filter.UserID,
+			filter.IP != nil && !session.IP.Equal(filter.IP),
+			!strings.Contains(strings.ToLower(session.UserAgent), userAgent),
+			!filter.CreatedFrom.IsZero() && session.CreatedAt.Before(filter.CreatedFrom),
+			!filter.CreatedTo.IsZero() && !session.CreatedAt.Before(filter.CreatedTo),
+			filter.ActiveOnly && (session.IsRevorked || !session.ExpiresAt.After(now)):
+			continue
+		}
+		sessions = append(sessions, cloneSession(session))
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].CreatedAt.After(sessions[j].CreatedAt)
+	})
+
+	if filter.Limit > 0 && len(sessions) > filter.Limit {
+		sessions = sessions[:filter.Limit]
+	}
+
+	return sessions, nil
+}
+
+func (r *AuthRepo) RevokeSessions(ctx context.Context, ids []uuid.UUID) ([]entity.Session, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	revoked := []entity.Session{}
+	for _, id := range ids {
+		session, ok := r.store.sessions[id]
+		if !ok || session.IsRevorked {
+			continue
+		}
+
+		session.IsRevorked = true
+		r.store.sessions[id] = session
+		revoked = append(revoked, cloneSession(session))
+	}
+
+	return revoked, nil
+}
+
+func (r *AuthRepo) RevokeUserSessions(ctx context.Context, userID uuid.UUID) (int64, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var revoked int64
+	for id, session := range r.store.sessions {
+		if session.UserId == userID && !session.IsRevorked {
+			session.IsRevorked = true
+			r.store.sessions[id] = session
+			revoked++
+		}
+	}
+
+	return revoked, nil
+}
+
+func (r *AuthRepo) DeleteExpiredSessions(ctx context.Context, before time.Time) (int64, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var deleted int64
+	for id, session := range r.store.sessions {
+		if session.ExpiresAt.Before(before) {
+			delete(r.store.sessions, id)
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
+
+// revoke must be called with the store lock held.
+func (r *AuthRepo) revoke(sessionID uuid.UUID) error {
+	session, ok := r.store.sessions[sessionID]
+	if !ok || session.IsRevorked {
+		return fmt.Errorf("session %w or already revoked", repo.ErrNotFound)
+	}
+
+	session.IsRevorked = true
+	r.store.sessions[sessionID] = session
+
+	return nil
+}
+
+func cloneSession(session entity.Session) entity.Session {
+	session.IP = append(net.IP{}, session.IP...)
+	return session
+}
+
+func (r *AuthRepo) ListUsers(ctx context.Context, limit int) ([]entity.UserSummary, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	now := time.Now()
+	summaries := make(map[uuid.UUID]*entity.UserSummary)
+	for _, session := range r.store.sessions {
+		summary, ok := summaries[session.UserId]
+		if !ok {
+			summary = &entity.UserSummary{UserID: session.UserId}
+			summaries[session.UserId] = summary
+		}
+
+		summary.Sessions++
+		if !session.IsRevorked && session.ExpiresAt.After(now) {
+			summary.ActiveSessions++
+		}
+		if session.CreatedAt.After(summary.LastLoginAt) {
+			summary.LastLoginAt = session.CreatedAt
+		}
+	}
+
+	users := make([]entity.UserSummary, 0, len(summaries))
+	for _, summary := range summaries {
+		users = append(users, *summary)
+	}
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].LastLoginAt.After(users[j].LastLoginAt)
+	})
+
+	if limit > 0 && len(users) > limit {
+		users = users[:limit]
+	}
+
+	return users, nil
+}
@@ -0,0 +1,57 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sort"
+
+	"github.com/BabyJhon/medods-test-task/internal/entity"
+	"github.com/gofrs/uuid"
+)
+
+type EventsRepo struct {
+	store *store
+}
+
+func (r *EventsRepo) CreateEvent(ctx context.Context, event entity.SecurityEvent) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, existing := range r.store.events {
+		if existing.ID == event.ID {
+			return errors.New("event already exists")
+		}
+	}
+	r.store.events = append(r.store.events, cloneEvent(event))
+
+	return nil
+}
+
+func (r *EventsRepo) GetUserEvents(ctx context.Context, userID uuid.UUID, limit int) ([]entity.SecurityEvent, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	events := []entity.SecurityEvent{}
+	for _, event := range r.store.events {
+		if event.UserID == userID {
+			events = append(events, cloneEvent(event))
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].CreatedAt.After(events[j].CreatedAt)
+	})
+
+	if len(events) > limit {
+		events = events[:limit]
+	}
+
+	return events, nil
+}
+
+func cloneEvent(event entity.SecurityEvent) entity.SecurityEvent {
+	if event.IP != nil {
+		event.IP = append(net.IP{}, event.IP...)
+	}
+	return event
+}
@@ -0,0 +1,55 @@
+package memory
+
+import (
+	"sync"
+	"time"
+
+	"github.com/BabyJhon/medods-test-task/internal/entity"
+	"github.com/BabyJhon/medods-test-task/internal/repo"
+	"github.com/gofrs/uuid"
+)
+
+// store holds the data of all in-memory repos behind one lock, so operations that touch
+// several records (like RefreshTokens) are atomic the same way a Postgres transaction is.
+type store struct {
+	mu        sync.RWMutex
+	sessions  map[uuid.UUID]entity.Session
+	codes     map[string]entity.AuthorizationCode
+	clients   map[string]entity.OAuthClient
+	roles     map[string]entity.Role
+	userRoles map[uuid.UUID]map[string]time.Time
+	events    []entity.SecurityEvent
+	webhooks  []entity.WebhookDelivery
+}
+
+func newStore() *store {
+	return &store{
+		sessions: make(map[uuid.UUID]entity.Session),
+		codes:    make(map[string]entity.AuthorizationCode),
+		clients:  make(map[string]entity.OAuthClient),
+		// Same seed as the roles migration.
+		roles: map[string]entity.Role{
+			"admin": {Name: "admin", Permissions: []string{"admin"}, CreatedAt: time.Now()},
+		},
+		userRoles: make(map[uuid.UUID]map[string]time.Time),
+	}
+}
+
+func NewRepository() *repo.Repository {
+	s := newStore()
+
+	return &repo.Repository{
+		Auth:     &AuthRepo{store: s},
+		OAuth:    &OAuthRepo{store: s},
+		RBAC:     &RBACRepo{store: s},
+		Events:   &EventsRepo{store: s},
+		Webhooks: &WebhooksRepo{store: s},
+	}
+}
+
+func cloneStrings(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return append([]string{}, values...)
+}
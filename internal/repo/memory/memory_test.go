@@ -0,0 +1,15 @@
+package memory_test
+
+import (
+	"testing"
+
+	"github.com/BabyJhon/medods-test-task/internal/repo"
+	"github.com/BabyJhon/medods-test-task/internal/repo/memory"
+	"github.com/BabyJhon/medods-test-task/internal/repo/repotest"
+)
+
+func TestConformance(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) *repo.Repository {
+		return memory.NewRepository()
+	})
+}
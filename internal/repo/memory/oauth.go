@@ -0,0 +1,101 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/BabyJhon/medods-test-task/internal/entity"
+	"github.com/BabyJhon/medods-test-task/internal/repo"
+)
+
+type OAuthRepo struct {
+	store *store
+}
+
+func (r *OAuthRepo) CreateAuthorizationCode(ctx context.Context, code entity.AuthorizationCode) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, exists := r.store.codes[code.CodeHash]; exists {
+		return errors.New("authorization code already exists")
+	}
+	r.store.codes[code.CodeHash] = code
+
+	return nil
+}
+
+func (r *OAuthRepo) UseAuthorizationCode(ctx context.Context, codeHash string) (entity.AuthorizationCode, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	code, ok := r.store.codes[codeHash]
+	if !ok || code.IsUsed {
+		return entity.AuthorizationCode{}, fmt.Errorf("authorization code %w or already used", repo.ErrNotFound)
+	}
+
+	code.IsUsed = true
+	r.store.codes[codeHash] = code
+
+	return code, nil
+}
+
+func (r *OAuthRepo) CreateClient(ctx context.Context, client entity.OAuthClient) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, exists := r.store.clients[client.ClientID]; exists {
+		return errors.New("client already exists")
+	}
+	r.store.clients[client.ClientID] = cloneClient(client)
+
+	return nil
+}
+
+func (r *OAuthRepo) GetClientByID(ctx context.Context, clientID string) (entity.OAuthClient, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	client, ok := r.store.clients[clientID]
+	if !ok {
+		return entity.OAuthClient{}, fmt.Errorf("client %w", repo.ErrNotFound)
+	}
+
+	return cloneClient(client), nil
+}
+
+func (r *OAuthRepo) UpdateClientSecret(ctx context.Context, clientID, secretHash string) error {
+	return r.updateClient(clientID, func(client *entity.OAuthClient) {
+		client.SecretHash = secretHash
+	})
+}
+
+func (r *OAuthRepo) SetClientDisabled(ctx context.Context, clientID string, disabled bool) error {
+	return r.updateClient(clientID, func(client *entity.OAuthClient) {
+		client.IsDisabled = disabled
+	})
+}
+
+func (r *OAuthRepo) updateClient(clientID string, update func(client *entity.OAuthClient)) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	client, ok := r.store.clients[clientID]
+	if !ok {
+		return fmt.Errorf("client %w", repo.ErrNotFound)
+	}
+
+	update(&client)
+	client.UpdatedAt = time.Now()
+	r.store.clients[clientID] = client
+
+	return nil
+}
+
+func cloneClient(client entity.OAuthClient) entity.OAuthClient {
+	client.GrantTypes = cloneStrings(client.GrantTypes)
+	client.Scopes = cloneStrings(client.Scopes)
+	client.RedirectURIs = cloneStrings(client.RedirectURIs)
+	return client
+}
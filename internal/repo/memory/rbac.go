@@ -0,0 +1,98 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/BabyJhon/medods-test-task/internal/entity"
+	"github.com/BabyJhon/medods-test-task/internal/repo"
+	"github.com/gofrs/uuid"
+)
+
+type RBACRepo struct {
+	store *store
+}
+
+func (r *RBACRepo) GetUserRoles(ctx context.Context, userID uuid.UUID) ([]entity.Role, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	roles := []entity.Role{}
+	for name := range r.store.userRoles[userID] {
+		if role, ok := r.store.roles[name]; ok {
+			roles = append(roles, cloneRole(role))
+		}
+	}
+	sortRoles(roles)
+
+	return roles, nil
+}
+
+func (r *RBACRepo) ListRoles(ctx context.Context) ([]entity.Role, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	roles := make([]entity.Role, 0, len(r.store.roles))
+	for _, role := range r.store.roles {
+		roles = append(roles, cloneRole(role))
+	}
+	sortRoles(roles)
+
+	return roles, nil
+}
+
+func (r *RBACRepo) UpsertRole(ctx context.Context, role entity.Role) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if existing, ok := r.store.roles[role.Name]; ok {
+		role.CreatedAt = existing.CreatedAt
+	}
+	r.store.roles[role.Name] = cloneRole(role)
+
+	return nil
+}
+
+func (r *RBACRepo) AssignRole(ctx context.Context, userID uuid.UUID, role string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.roles[role]; !ok {
+		return fmt.Errorf("role %w or already assigned", repo.ErrNotFound)
+	}
+	if _, ok := r.store.userRoles[userID][role]; ok {
+		return fmt.Errorf("role %w or already assigned", repo.ErrNotFound)
+	}
+
+	if r.store.userRoles[userID] == nil {
+		r.store.userRoles[userID] = make(map[string]time.Time)
+	}
+	r.store.userRoles[userID][role] = time.Now()
+
+	return nil
+}
+
+func (r *RBACRepo) RemoveRole(ctx context.Context, userID uuid.UUID, role string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.userRoles[userID][role]; !ok {
+		return fmt.Errorf("role assignment %w", repo.ErrNotFound)
+	}
+	delete(r.store.userRoles[userID], role)
+
+	return nil
+}
+
+func cloneRole(role entity.Role) entity.Role {
+	role.Permissions = cloneStrings(role.Permissions)
+	return role
+}
+
+func sortRoles(roles []entity.Role) {
+	sort.Slice(roles, func(i, j int) bool {
+		return roles[i].Name < roles[j].Name
+	})
+}
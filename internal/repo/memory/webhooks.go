@@ -0,0 +1,43 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"github.com/BabyJhon/medods-test-task/internal/entity"
+)
+
+type WebhooksRepo struct {
+	store *store
+}
+
+func (r *WebhooksRepo) CreateDelivery(ctx context.Context, delivery entity.WebhookDelivery) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, existing := range r.store.webhooks {
+		if existing.ID == delivery.ID {
+			return errors.New("delivery already exists")
+		}
+	}
+	r.store.webhooks = append(r.store.webhooks, delivery)
+
+	return nil
+}
+
+func (r *WebhooksRepo) ListDeliveries(ctx context.Context, limit int) ([]entity.WebhookDelivery, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	deliveries := append([]entity.WebhookDelivery{}, r.store.webhooks...)
+	sort.SliceStable(deliveries, func(i, j int) bool {
+		return deliveries[i].CreatedAt.After(deliveries[j].CreatedAt)
+	})
+
+	if len(deliveries) > limit {
+		deliveries = deliveries[:limit]
+	}
+
+	return deliveries, nil
+}
@@ -0,0 +1,98 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/BabyJhon/medods-test-task/internal/entity"
+	"github.com/BabyJhon/medods-test-task/pkg/postgres"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type OAuthRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewOAuthRepo(db *pgxpool.Pool) *OAuthRepo {
+	return &OAuthRepo{
+		db: db,
+	}
+}
+
+func (r *OAuthRepo) CreateAuthorizationCode(ctx context.Context, code entity.AuthorizationCode) error {
+	query := fmt.Sprintf("INSERT INTO %s (code_hash, client_id, user_id, redirect_uri, code_challenge, code_challenge_method, scope, nonce, auth_time, created_at, expires_at, is_used) values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)", postgres.AuthorizationCodeTable)
+
+	_, err := r.db.Exec(ctx, query, code.CodeHash, code.ClientID, code.UserID, code.RedirectURI, code.CodeChallenge, code.CodeChallengeMethod, code.Scope, code.Nonce, code.AuthTime, code.CreatedAt, code.ExpiresAt, code.IsUsed)
+	return err
+}
+
+// UseAuthorizationCode marks the code as used and returns it. A code can be used only once,
+// so a second call with the same hash fails even if the first exchange did not finish.
+func (r *OAuthRepo) UseAuthorizationCode(ctx context.Context, codeHash string) (entity.AuthorizationCode, error) {
+	var code entity.AuthorizationCode
+
+	query := fmt.Sprintf("UPDATE %s SET is_used = true WHERE code_hash = $1 AND is_used = false RETURNING code_hash, client_id, user_id, redirect_uri, code_challenge, code_challenge_method, scope, nonce, auth_time, created_at, expires_at, is_used", postgres.AuthorizationCodeTable)
+
+	row := r.db.QueryRow(ctx, query, codeHash)
+	if err := row.Scan(&code.CodeHash, &code.ClientID, &code.UserID, &code.RedirectURI, &code.CodeChallenge, &code.CodeChallengeMethod, &code.Scope, &code.Nonce, &code.AuthTime, &code.CreatedAt, &code.ExpiresAt, &code.IsUsed); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.AuthorizationCode{}, fmt.Errorf("authorization code %w or already used", ErrNotFound)
+		}
+		return entity.AuthorizationCode{}, err
+	}
+
+	return code, nil
+}
+
+func (r *OAuthRepo) CreateClient(ctx context.Context, client entity.OAuthClient) error {
+	query := fmt.Sprintf("INSERT INTO %s (client_id, secret_hash, name, grant_types, scopes, redirect_uris, is_public, is_disabled, dpop_bound_access_tokens, created_at, updated_at) values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)", postgres.OAuthClientTable)
+
+	_, err := r.db.Exec(ctx, query, client.ClientID, client.SecretHash, client.Name, client.GrantTypes, client.Scopes, client.RedirectURIs, client.IsPublic, client.IsDisabled, client.DPoPBoundAccessTokens, client.CreatedAt, client.UpdatedAt)
+	return err
+}
+
+func (r *OAuthRepo) GetClientByID(ctx context.Context, clientID string) (entity.OAuthClient, error) {
+	var client entity.OAuthClient
+
+	query := fmt.Sprintf("SELECT client_id, secret_hash, name, grant_types, scopes, redirect_uris, is_public, is_disabled, dpop_bound_access_tokens, created_at, updated_at FROM %s WHERE client_id = $1", postgres.OAuthClientTable)
+
+	row := r.db.QueryRow(ctx, query, clientID)
+	if err := row.Scan(&client.ClientID, &client.SecretHash, &client.Name, &client.GrantTypes, &client.Scopes, &client.RedirectURIs, &client.IsPublic, &client.IsDisabled, &client.DPoPBoundAccessTokens, &client.CreatedAt, &client.UpdatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.OAuthClient{}, fmt.Errorf("client %w", ErrNotFound)
+		}
+		return entity.OAuthClient{}, err
+	}
+
+	return client, nil
+}
+
+func (r *OAuthRepo) UpdateClientSecret(ctx context.Context, clientID, secretHash string) error {
+	query := fmt.Sprintf("UPDATE %s SET secret_hash = $2, updated_at = NOW() WHERE client_id = $1", postgres.OAuthClientTable)
+	result, err := r.db.Exec(ctx, query, clientID, secretHash)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("client %w", ErrNotFound)
+	}
+
+	return nil
+}
+
+func (r *OAuthRepo) SetClientDisabled(ctx context.Context, clientID string, disabled bool) error {
+	query := fmt.Sprintf("UPDATE %s SET is_disabled = $2, updated_at = NOW() WHERE client_id = $1", postgres.OAuthClientTable)
+	result, err := r.db.Exec(ctx, query, clientID, disabled)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("client %w", ErrNotFound)
+	}
+
+	return nil
+}
@@ -0,0 +1,90 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/BabyJhon/medods-test-task/internal/entity"
+	"github.com/BabyJhon/medods-test-task/pkg/postgres"
+	"github.com/gofrs/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type RBACRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewRBACRepo(db *pgxpool.Pool) *RBACRepo {
+	return &RBACRepo{
+		db: db,
+	}
+}
+
+func (r *RBACRepo) GetUserRoles(ctx context.Context, userID uuid.UUID) ([]entity.Role, error) {
+	query := fmt.Sprintf("SELECT r.name, r.permissions, r.created_at FROM %s r JOIN %s ur ON ur.role = r.name WHERE ur.user_id = $1 ORDER BY r.name", postgres.RoleTable, postgres.UserRoleTable)
+
+	return r.queryRoles(ctx, query, userID)
+}
+
+func (r *RBACRepo) ListRoles(ctx context.Context) ([]entity.Role, error) {
+	query := fmt.Sprintf("SELECT name, permissions, created_at FROM %s ORDER BY name", postgres.RoleTable)
+
+	return r.queryRoles(ctx, query)
+}
+
+func (r *RBACRepo) UpsertRole(ctx context.Context, role entity.Role) error {
+	query := fmt.Sprintf("INSERT INTO %s (name, permissions, created_at) values ($1, $2, $3) ON CONFLICT (name) DO UPDATE SET permissions = EXCLUDED.permissions", postgres.RoleTable)
+
+	_, err := r.db.Exec(ctx, query, role.Name, role.Permissions, role.CreatedAt)
+	return err
+}
+
+func (r *RBACRepo) AssignRole(ctx context.Context, userID uuid.UUID, role string) error {
+	query := fmt.Sprintf("INSERT INTO %s (user_id, role) SELECT $1, name FROM %s WHERE name = $2 ON CONFLICT DO NOTHING", postgres.UserRoleTable, postgres.RoleTable)
+
+	result, err := r.db.Exec(ctx, query, userID, role)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("role %w or already assigned", ErrNotFound)
+	}
+
+	return nil
+}
+
+func (r *RBACRepo) RemoveRole(ctx context.Context, userID uuid.UUID, role string) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE user_id = $1 AND role = $2", postgres.UserRoleTable)
+
+	result, err := r.db.Exec(ctx, query, userID, role)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("role assignment %w", ErrNotFound)
+	}
+
+	return nil
+}
+
+func (r *RBACRepo) queryRoles(ctx context.Context, query string, args ...any) ([]entity.Role, error) {
+	roles := []entity.Role{}
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var role entity.Role
+		if err := rows.Scan(&role.Name, &role.Permissions, &role.CreatedAt); err != nil {
+			return nil, err
+		}
+		roles = append(roles, role)
+	}
+
+	return roles, rows.Err()
+}
@@ -2,26 +2,76 @@ package repo
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/BabyJhon/medods-test-task/internal/entity"
 	"github.com/gofrs/uuid"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// ErrNotFound is wrapped into the errors of all backends when the requested session,
+// client, authorization code, role or role assignment doesn't exist.
+var ErrNotFound = errors.New("not found")
+
 type Auth interface {
 	CreateSession(ctx context.Context, session entity.Session) (uuid.UUID, error)
 	GetSessionByID(ctx context.Context, sessionID uuid.UUID) (entity.Session, error)
 	RevokeToken(ctx context.Context, sessionID uuid.UUID) error
 	RefreshTokens(ctx context.Context, oldSession, newSession entity.Session) (uuid.UUID, error)
-	GetAllSessions(ctx context.Context) ([]*entity.Session, error) 
+	GetAllSessions(ctx context.Context) ([]*entity.Session, error)
+	GetSessionsByUser(ctx context.Context, userID uuid.UUID) ([]entity.Session, error)
+	RevokeUserSessions(ctx context.Context, userID uuid.UUID) (int64, error)
+	DeleteExpiredSessions(ctx context.Context, before time.Time) (int64, error)
+	FindSessions(ctx context.Context, filter entity.SessionFilter) ([]entity.Session, error)
+	// RevokeSessions revokes the active sessions among ids and returns them.
+	RevokeSessions(ctx context.Context, ids []uuid.UUID) ([]entity.Session, error)
+	// ListUsers returns users that have sessions, most recently logged in first. A limit of
+	// 0 returns all of them.
+	ListUsers(ctx context.Context, limit int) ([]entity.UserSummary, error)
+}
+
+type OAuth interface {
+	CreateAuthorizationCode(ctx context.Context, code entity.AuthorizationCode) error
+	UseAuthorizationCode(ctx context.Context, codeHash string) (entity.AuthorizationCode, error)
+	CreateClient(ctx context.Context, client entity.OAuthClient) error
+	GetClientByID(ctx context.Context, clientID string) (entity.OAuthClient, error)
+	UpdateClientSecret(ctx context.Context, clientID, secretHash string) error
+	SetClientDisabled(ctx context.Context, clientID string, disabled bool) error
+}
+
+type RBAC interface {
+	GetUserRoles(ctx context.Context, userID uuid.UUID) ([]entity.Role, error)
+	ListRoles(ctx context.Context) ([]entity.Role, error)
+	UpsertRole(ctx context.Context, role entity.Role) error
+	AssignRole(ctx context.Context, userID uuid.UUID, role string) error
+	RemoveRole(ctx context.Context, userID uuid.UUID, role string) error
+}
+
+type Events interface {
+	CreateEvent(ctx context.Context, event entity.SecurityEvent) error
+	GetUserEvents(ctx context.Context, userID uuid.UUID, limit int) ([]entity.SecurityEvent, error)
+}
+
+type Webhooks interface {
+	CreateDelivery(ctx context.Context, delivery entity.WebhookDelivery) error
+	ListDeliveries(ctx context.Context, limit int) ([]entity.WebhookDelivery, error)
 }
 
 type Repository struct {
 	Auth
+	OAuth
+	RBAC
+	Events
+	Webhooks
 }
 
 func NewRepository(db *pgxpool.Pool) *Repository {
 	return &Repository{
-		Auth: NewAuthRepo(db),
+		Auth:     NewAuthRepo(db),
+		OAuth:    NewOAuthRepo(db),
+		RBAC:     NewRBACRepo(db),
+		Events:   NewEventsRepo(db),
+		Webhooks: NewWebhooksRepo(db),
 	}
 }
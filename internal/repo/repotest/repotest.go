@@ -0,0 +1,779 @@
+// Package repotest is the conformance suite for storage backends. Every implementation of
+// the repo interfaces must pass Run:
+//
+//	func TestConformance(t *testing.T) {
+//		repotest.Run(t, func(t *testing.T) *repo.Repository {
+//			return memory.NewRepository()
+//		})
+//	}
+//
+// newRepository must return an empty repository (apart from the seeded admin role) on every call.
+package repotest
+
+import (
+	"context"
+	"errors"
+	"net"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/BabyJhon/medods-test-task/internal/entity"
+	"github.com/BabyJhon/medods-test-task/internal/repo"
+	"github.com/gofrs/uuid"
+)
+
+func Run(t *testing.T, newRepository func(t *testing.T) *repo.Repository) {
+	t.Run("Auth", func(t *testing.T) {
+		runAuth(t, newRepository)
+	})
+	t.Run("OAuth", func(t *testing.T) {
+		runOAuth(t, newRepository)
+	})
+	t.Run("RBAC", func(t *testing.T) {
+		runRBAC(t, newRepository)
+	})
+	t.Run("Events", func(t *testing.T) {
+		runEvents(t, newRepository)
+	})
+	t.Run("Webhooks", func(t *testing.T) {
+		runWebhooks(t, newRepository)
+	})
+}
+
+func runAuth(t *testing.T, newRepository func(t *testing.T) *repo.Repository) {
+	ctx := context.Background()
+
+	t.Run("CreateAndGetSession", func(t *testing.T) {
+		r := newRepository(t)
+		session := newSession(t)
+
+		id, err := r.CreateSession(ctx, session)
+		if err != nil {
+			t.Fatalf("CreateSession: %v", err)
+		}
+		if id != session.ID {
+			t.Fatalf("CreateSession returned id %s, want %s", id, session.ID)
+		}
+
+		got, err := r.GetSessionByID(ctx, session.ID)
+		if err != nil {
+			t.Fatalf("GetSessionByID: %v", err)
+		}
+		assertSession(t, got, session)
+	})
+
+	t.Run("GetMissingSession", func(t *testing.T) {
+		r := newRepository(t)
+
+		if _, err := r.GetSessionByID(ctx, newUUID(t)); !errors.Is(err, repo.ErrNotFound) {
+			t.Fatalf("GetSessionByID of missing session: want ErrNotFound, got %v", err)
+		}
+	})
+
+	t.Run("CreateDuplicateSession", func(t *testing.T) {
+		r := newRepository(t)
+		session := newSession(t)
+
+		mustCreateSession(t, r, session)
+		if _, err := r.CreateSession(ctx, session); err == nil {
+			t.Fatal("CreateSession with existing id: want error")
+		}
+	})
+
+	t.Run("RevokeToken", func(t *testing.T) {
+		r := newRepository(t)
+		session := newSession(t)
+		mustCreateSession(t, r, session)
+
+		if err := r.RevokeToken(ctx, session.ID); err != nil {
+			t.Fatalf("RevokeToken: %v", err)
+		}
+
+		got, err := r.GetSessionByID(ctx, session.ID)
+		if err != nil {
+			t.Fatalf("GetSessionByID: %v", err)
+		}
+		if !got.IsRevorked {
+			t.Fatal("session is not revoked")
+		}
+
+		if err := r.RevokeToken(ctx, session.ID); !errors.Is(err, repo.ErrNotFound) {
+			t.Fatalf("RevokeToken of revoked session: want ErrNotFound, got %v", err)
+		}
+		if err := r.RevokeToken(ctx, newUUID(t)); !errors.Is(err, repo.ErrNotFound) {
+			t.Fatalf("RevokeToken of missing session: want ErrNotFound, got %v", err)
+		}
+	})
+
+	t.Run("GetAllSessionsSkipsRevoked", func(t *testing.T) {
+		r := newRepository(t)
+		active, revoked := newSession(t), newSession(t)
+		mustCreateSession(t, r, active)
+		mustCreateSession(t, r, revoked)
+
+		if err := r.RevokeToken(ctx, revoked.ID); err != nil {
+			t.Fatalf("RevokeToken: %v", err)
+		}
+
+		sessions, err := r.GetAllSessions(ctx)
+		if err != nil {
+			t.Fatalf("GetAllSessions: %v", err)
+		}
+		if len(sessions) != 1 || sessions[0].ID != active.ID {
+			t.Fatalf("GetAllSessions returned %d sessions, want only %s", len(sessions), active.ID)
+		}
+	})
+
+	t.Run("GetSessionsByUser", func(t *testing.T) {
+		r := newRepository(t)
+		older := newSession(t)
+		older.CreatedAt = older.CreatedAt.Add(-time.Minute)
+		newer := newSessionLike(t, older)
+		newer.CreatedAt = older.CreatedAt.Add(time.Minute)
+		mustCreateSession(t, r, older)
+		mustCreateSession(t, r, newer)
+		mustCreateSession(t, r, newSession(t))
+
+		if err := r.RevokeToken(ctx, older.ID); err != nil {
+			t.Fatalf("RevokeToken: %v", err)
+		}
+		older.IsRevorked = true
+
+		sessions, err := r.GetSessionsByUser(ctx, older.UserId)
+		if err != nil {
+			t.Fatalf("GetSessionsByUser: %v", err)
+		}
+		if len(sessions) != 2 {
+			t.Fatalf("GetSessionsByUser returned %d sessions, want 2", len(sessions))
+		}
+		assertSession(t, sessions[0], newer)
+		assertSession(t, sessions[1], older)
+
+		sessions, err = r.GetSessionsByUser(ctx, newUUID(t))
+		if err != nil {
+			t.Fatalf("GetSessionsByUser: %v", err)
+		}
+		if len(sessions) != 0 {
+			t.Fatalf("GetSessionsByUser of user without sessions returned %d sessions", len(sessions))
+		}
+	})
+
+	t.Run("RevokeUserSessions", func(t *testing.T) {
+		r := newRepository(t)
+		first := newSession(t)
+		second, revoked, other := newSessionLike(t, first), newSessionLike(t, first), newSession(t)
+		for _, session := range []entity.Session{first, second, revoked, other} {
+			mustCreateSession(t, r, session)
+		}
+		if err := r.RevokeToken(ctx, revoked.ID); err != nil {
+			t.Fatalf("RevokeToken: %v", err)
+		}
+
+		count, err := r.RevokeUserSessions(ctx, first.UserId)
+		if err != nil {
+			t.Fatalf("RevokeUserSessions: %v", err)
+		}
+		if count != 2 {
+			t.Fatalf("RevokeUserSessions revoked %d sessions, want 2", count)
+		}
+
+		for _, id := range []uuid.UUID{first.ID, second.ID} {
+			got, err := r.GetSessionByID(ctx, id)
+			if err != nil {
+				t.Fatalf("GetSessionByID: %v", err)
+			}
+			if !got.IsRevorked {
+				t.Fatalf("session %s is not revoked", id)
+			}
+		}
+		got, err := r.GetSessionByID(ctx, other.ID)
+		if err != nil {
+			t.Fatalf("GetSessionByID: %v", err)
+		}
+		if got.IsRevorked {
+			t.Fatal("RevokeUserSessions revoked a session of another user")
+		}
+	})
+
+	t.Run("FindSessions", func(t *testing.T) {
+		r := newRepository(t)
+		now := truncate(time.Now())
+
+		first := newSession(t)
+		first.UserAgent = "Mozilla/5.0 Firefox"
+		first.CreatedAt = now.Add(-2 * time.Hour)
+		second := newSessionLike(t, first)
+		second.IP = net.ParseIP("2001:db8::1")
+		second.UserAgent = "okhttp/4.9"
+		second.CreatedAt = now.Add(-time.Hour)
+		revoked := newSessionLike(t, first)
+		revoked.CreatedAt = now
+		expired := newSessionLike(t, first)
+		expired.CreatedAt = now.Add(-3 * time.Hour)
+		expired.ExpiresAt = now.Add(-time.Minute)
+		other := newSession(t)
+		for _, session := range []entity.Session{first, second, revoked, expired, other} {
+			mustCreateSession(t, r, session)
+		}
+		if err := r.RevokeToken(ctx, revoked.ID); err != nil {
+			t.Fatalf("RevokeToken: %v", err)
+		}
+
+		tests := []struct {
+			name   string
+			filter entity.SessionFilter
+			want   []uuid.UUID
+		}{
+			{"User", entity.SessionFilter{UserID: first.UserId}, []uuid.UUID{revoked.ID, second.ID, first.ID, expired.ID}},
+			{"IP", entity.SessionFilter{UserID: first.UserId, IP: net.ParseIP("2001:db8::1")}, []uuid.UUID{second.ID}},
+			{"UserAgent", entity.SessionFilter{UserID: first.UserId, UserAgent: "FIREFOX"}, []uuid.UUID{revoked.ID, first.ID, expired.ID}},
+			{"CreatedRange", entity.SessionFilter{UserID: first.UserId, CreatedFrom: now.Add(-2 * time.Hour), CreatedTo: now}, []uuid.UUID{second.ID, first.ID}},
+			{"ActiveOnly", entity.SessionFilter{UserID: first.UserId, ActiveOnly: true}, []uuid.UUID{second.ID, first.ID}},
+			{"Limit", entity.SessionFilter{UserID: first.UserId, Limit: 2}, []uuid.UUID{revoked.ID, second.ID}},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				sessions, err := r.FindSessions(ctx, tt.filter)
+				if err != nil {
+					t.Fatalf("FindSessions: %v", err)
+				}
+
+				got := make([]uuid.UUID, 0, len(sessions))
+				for _, session := range sessions {
+					got = append(got, session.ID)
+				}
+				if !slices.Equal(got, tt.want) {
+					t.Fatalf("FindSessions returned %v, want %v", got, tt.want)
+				}
+			})
+		}
+	})
+
+	t.Run("RevokeSessions", func(t *testing.T) {
+		r := newRepository(t)
+		first, second, revoked, untouched := newSession(t), newSession(t), newSession(t), newSession(t)
+		for _, session := range []entity.Session{first, second, revoked, untouched} {
+			mustCreateSession(t, r, session)
+		}
+		if err := r.RevokeToken(ctx, revoked.ID); err != nil {
+			t.Fatalf("RevokeToken: %v", err)
+		}
+
+		sessions, err := r.RevokeSessions(ctx, []uuid.UUID{first.ID, second.ID, revoked.ID, newUUID(t)})
+		if err != nil {
+			t.Fatalf("RevokeSessions: %v", err)
+		}
+		if len(sessions) != 2 {
+			t.Fatalf("RevokeSessions returned %d sessions, want 2", len(sessions))
+		}
+		for _, session := range sessions {
+			if session.ID != first.ID && session.ID != second.ID {
+				t.Fatalf("RevokeSessions returned unexpected session %s", session.ID)
+			}
+			if !session.IsRevorked {
+				t.Fatalf("RevokeSessions returned session %s as not revoked", session.ID)
+			}
+		}
+
+		got, err := r.GetSessionByID(ctx, untouched.ID)
+		if err != nil {
+			t.Fatalf("GetSessionByID: %v", err)
+		}
+		if got.IsRevorked {
+			t.Fatal("RevokeSessions revoked a session that was not requested")
+		}
+
+		sessions, err = r.RevokeSessions(ctx, nil)
+		if err != nil {
+			t.Fatalf("RevokeSessions(nil): %v", err)
+		}
+		if len(sessions) != 0 {
+			t.Fatalf("RevokeSessions(nil) returned %d sessions", len(sessions))
+		}
+	})
+
+	t.Run("ListUsers", func(t *testing.T) {
+		r := newRepository(t)
+		revoked := newSession(t)
+		revoked.CreatedAt = revoked.CreatedAt.Add(-time.Hour)
+		active := newSessionLike(t, revoked)
+		active.CreatedAt = revoked.CreatedAt.Add(time.Minute)
+		expired := newSessionLike(t, revoked)
+		expired.CreatedAt = revoked.CreatedAt.Add(-time.Minute)
+		expired.ExpiresAt = truncate(time.Now().Add(-time.Minute))
+		latest := newSession(t)
+		for _, session := range []entity.Session{revoked, active, expired, latest} {
+			mustCreateSession(t, r, session)
+		}
+		if err := r.RevokeToken(ctx, revoked.ID); err != nil {
+			t.Fatalf("RevokeToken: %v", err)
+		}
+
+		users, err := r.ListUsers(ctx, 10)
+		if err != nil {
+			t.Fatalf("ListUsers: %v", err)
+		}
+		want := []entity.UserSummary{
+			{UserID: latest.UserId, Sessions: 1, ActiveSessions: 1, LastLoginAt: latest.CreatedAt},
+			{UserID: active.UserId, Sessions: 3, ActiveSessions: 1, LastLoginAt: active.CreatedAt},
+		}
+		if len(users) != len(want) {
+			t.Fatalf("ListUsers returned %+v, want %+v", users, want)
+		}
+		for i := range want {
+			if users[i].UserID != want[i].UserID || users[i].Sessions != want[i].Sessions ||
+				users[i].ActiveSessions != want[i].ActiveSessions || !users[i].LastLoginAt.Equal(want[i].LastLoginAt) {
+				t.Fatalf("ListUsers()[%d] = %+v, want %+v", i, users[i], want[i])
+			}
+		}
+
+		users, err = r.ListUsers(ctx, 1)
+		if err != nil {
+			t.Fatalf("ListUsers: %v", err)
+		}
+		if len(users) != 1 || users[0].UserID != latest.UserId {
+			t.Fatalf("ListUsers with limit 1 returned %+v, want only the latest user", users)
+		}
+
+		users, err = r.ListUsers(ctx, 0)
+		if err != nil {
+			t.Fatalf("ListUsers: %v", err)
+		}
+		if len(users) != len(want) {
+			t.Fatalf("ListUsers without limit returned %+v, want %+v", users, want)
+		}
+	})
+
+	t.Run("DeleteExpiredSessions", func(t *testing.T) {
+		r := newRepository(t)
+		expired, active := newSession(t), newSession(t)
+		expired.ExpiresAt = truncate(time.Now().Add(-time.Hour))
+		mustCreateSession(t, r, expired)
+		mustCreateSession(t, r, active)
+
+		count, err := r.DeleteExpiredSessions(ctx, time.Now())
+		if err != nil {
+			t.Fatalf("DeleteExpiredSessions: %v", err)
+		}
+		if count != 1 {
+			t.Fatalf("DeleteExpiredSessions deleted %d sessions, want 1", count)
+		}
+		if _, err := r.GetSessionByID(ctx, expired.ID); err == nil {
+			t.Fatal("expired session is not deleted")
+		}
+		if _, err := r.GetSessionByID(ctx, active.ID); err != nil {
+			t.Fatalf("GetSessionByID(active): %v", err)
+		}
+	})
+
+	t.Run("RefreshTokens", func(t *testing.T) {
+		r := newRepository(t)
+		oldSession, newSession := newSession(t), newSession(t)
+		mustCreateSession(t, r, oldSession)
+
+		id, err := r.RefreshTokens(ctx, oldSession, newSession)
+		if err != nil {
+			t.Fatalf("RefreshTokens: %v", err)
+		}
+		if id != newSession.ID {
+			t.Fatalf("RefreshTokens returned id %s, want %s", id, newSession.ID)
+		}
+
+		got, err := r.GetSessionByID(ctx, oldSession.ID)
+		if err != nil {
+			t.Fatalf("GetSessionByID(old): %v", err)
+		}
+		if !got.IsRevorked {
+			t.Fatal("old session is not revoked")
+		}
+
+		got, err = r.GetSessionByID(ctx, newSession.ID)
+		if err != nil {
+			t.Fatalf("GetSessionByID(new): %v", err)
+		}
+		assertSession(t, got, newSession)
+	})
+
+	t.Run("RefreshTokensIsAtomic", func(t *testing.T) {
+		r := newRepository(t)
+		oldSession, firstSession, secondSession := newSession(t), newSession(t), newSession(t)
+		mustCreateSession(t, r, oldSession)
+
+		if _, err := r.RefreshTokens(ctx, oldSession, firstSession); err != nil {
+			t.Fatalf("RefreshTokens: %v", err)
+		}
+
+		// The old session is already revoked, so the second refresh must fail without
+		// creating its new session.
+		if _, err := r.RefreshTokens(ctx, oldSession, secondSession); err == nil {
+			t.Fatal("RefreshTokens of revoked session: want error")
+		}
+		if _, err := r.GetSessionByID(ctx, secondSession.ID); err == nil {
+			t.Fatal("failed RefreshTokens created the new session")
+		}
+
+		// A conflicting new session must not leave the old session revoked.
+		another := newSessionLike(t, oldSession)
+		mustCreateSession(t, r, another)
+		if _, err := r.RefreshTokens(ctx, another, firstSession); err == nil {
+			t.Fatal("RefreshTokens with existing new session id: want error")
+		}
+		got, err := r.GetSessionByID(ctx, another.ID)
+		if err != nil {
+			t.Fatalf("GetSessionByID: %v", err)
+		}
+		if got.IsRevorked {
+			t.Fatal("failed RefreshTokens revoked the old session")
+		}
+	})
+}
+
+func runOAuth(t *testing.T, newRepository func(t *testing.T) *repo.Repository) {
+	ctx := context.Background()
+
+	t.Run("AuthorizationCodeIsSingleUse", func(t *testing.T) {
+		r := newRepository(t)
+		code := entity.AuthorizationCode{
+			CodeHash:            "hash",
+			ClientID:            "client",
+			UserID:              newUUID(t),
+			RedirectURI:         "https://example.com/callback",
+			CodeChallenge:       "challenge",
+			CodeChallengeMethod: "S256",
+			Scope:               "openid",
+			Nonce:               "nonce",
+			AuthTime:            truncate(time.Now()),
+			CreatedAt:           truncate(time.Now()),
+			ExpiresAt:           truncate(time.Now().Add(time.Minute)),
+		}
+
+		if err := r.CreateAuthorizationCode(ctx, code); err != nil {
+			t.Fatalf("CreateAuthorizationCode: %v", err)
+		}
+
+		got, err := r.UseAuthorizationCode(ctx, code.CodeHash)
+		if err != nil {
+			t.Fatalf("UseAuthorizationCode: %v", err)
+		}
+		if got.ClientID != code.ClientID || got.UserID != code.UserID || got.RedirectURI != code.RedirectURI ||
+			got.CodeChallenge != code.CodeChallenge || got.Scope != code.Scope || got.Nonce != code.Nonce ||
+			!got.ExpiresAt.Equal(code.ExpiresAt) || !got.AuthTime.Equal(code.AuthTime) || !got.IsUsed {
+			t.Fatalf("UseAuthorizationCode returned %+v, want %+v", got, code)
+		}
+
+		if _, err := r.UseAuthorizationCode(ctx, code.CodeHash); !errors.Is(err, repo.ErrNotFound) {
+			t.Fatalf("second UseAuthorizationCode: want ErrNotFound, got %v", err)
+		}
+		if _, err := r.UseAuthorizationCode(ctx, "missing"); !errors.Is(err, repo.ErrNotFound) {
+			t.Fatalf("UseAuthorizationCode of missing code: want ErrNotFound, got %v", err)
+		}
+	})
+
+	t.Run("Clients", func(t *testing.T) {
+		r := newRepository(t)
+		client := entity.OAuthClient{
+			ClientID:              newUUID(t).String(),
+			SecretHash:            "hash",
+			Name:                  "client",
+			GrantTypes:            []string{"authorization_code", "refresh_token"},
+			Scopes:                []string{"openid"},
+			RedirectURIs:          []string{"https://example.com/callback"},
+			DPoPBoundAccessTokens: true,
+			CreatedAt:             truncate(time.Now()),
+			UpdatedAt:             truncate(time.Now()),
+		}
+
+		if err := r.CreateClient(ctx, client); err != nil {
+			t.Fatalf("CreateClient: %v", err)
+		}
+		if err := r.CreateClient(ctx, client); err == nil {
+			t.Fatal("CreateClient with existing id: want error")
+		}
+
+		got, err := r.GetClientByID(ctx, client.ClientID)
+		if err != nil {
+			t.Fatalf("GetClientByID: %v", err)
+		}
+		if got.Name != client.Name || got.SecretHash != client.SecretHash || !slices.Equal(got.GrantTypes, client.GrantTypes) ||
+			!slices.Equal(got.Scopes, client.Scopes) || !slices.Equal(got.RedirectURIs, client.RedirectURIs) ||
+			got.DPoPBoundAccessTokens != client.DPoPBoundAccessTokens {
+			t.Fatalf("GetClientByID returned %+v, want %+v", got, client)
+		}
+
+		if err := r.UpdateClientSecret(ctx, client.ClientID, "new-hash"); err != nil {
+			t.Fatalf("UpdateClientSecret: %v", err)
+		}
+		if err := r.SetClientDisabled(ctx, client.ClientID, true); err != nil {
+			t.Fatalf("SetClientDisabled: %v", err)
+		}
+
+		got, err = r.GetClientByID(ctx, client.ClientID)
+		if err != nil {
+			t.Fatalf("GetClientByID: %v", err)
+		}
+		if got.SecretHash != "new-hash" || !got.IsDisabled {
+			t.Fatalf("client was not updated: %+v", got)
+		}
+
+		if _, err := r.GetClientByID(ctx, "missing"); !errors.Is(err, repo.ErrNotFound) {
+			t.Fatalf("GetClientByID of missing client: want ErrNotFound, got %v", err)
+		}
+		if err := r.UpdateClientSecret(ctx, "missing", "hash"); !errors.Is(err, repo.ErrNotFound) {
+			t.Fatalf("UpdateClientSecret of missing client: want ErrNotFound, got %v", err)
+		}
+		if err := r.SetClientDisabled(ctx, "missing", true); !errors.Is(err, repo.ErrNotFound) {
+			t.Fatalf("SetClientDisabled of missing client: want ErrNotFound, got %v", err)
+		}
+	})
+}
+
+func runRBAC(t *testing.T, newRepository func(t *testing.T) *repo.Repository) {
+	ctx := context.Background()
+
+	t.Run("AdminRoleIsSeeded", func(t *testing.T) {
+		r := newRepository(t)
+
+		roles, err := r.ListRoles(ctx)
+		if err != nil {
+			t.Fatalf("ListRoles: %v", err)
+		}
+		if len(roles) != 1 || roles[0].Name != "admin" || !slices.Equal(roles[0].Permissions, []string{"admin"}) {
+			t.Fatalf("ListRoles returned %+v, want only seeded admin role", roles)
+		}
+	})
+
+	t.Run("UserRoles", func(t *testing.T) {
+		r := newRepository(t)
+		userID := newUUID(t)
+
+		if err := r.UpsertRole(ctx, entity.Role{Name: "doctor", Permissions: []string{"records:read"}, CreatedAt: time.Now()}); err != nil {
+			t.Fatalf("UpsertRole: %v", err)
+		}
+		if err := r.UpsertRole(ctx, entity.Role{Name: "doctor", Permissions: []string{"records:read", "records:write"}, CreatedAt: time.Now()}); err != nil {
+			t.Fatalf("UpsertRole(update): %v", err)
+		}
+
+		if err := r.AssignRole(ctx, userID, "doctor"); err != nil {
+			t.Fatalf("AssignRole: %v", err)
+		}
+		if err := r.AssignRole(ctx, userID, "admin"); err != nil {
+			t.Fatalf("AssignRole: %v", err)
+		}
+		if err := r.AssignRole(ctx, userID, "doctor"); err == nil {
+			t.Fatal("AssignRole of assigned role: want error")
+		}
+		if err := r.AssignRole(ctx, userID, "missing"); !errors.Is(err, repo.ErrNotFound) {
+			t.Fatalf("AssignRole of missing role: want ErrNotFound, got %v", err)
+		}
+
+		roles, err := r.GetUserRoles(ctx, userID)
+		if err != nil {
+			t.Fatalf("GetUserRoles: %v", err)
+		}
+		if len(roles) != 2 || roles[0].Name != "admin" || roles[1].Name != "doctor" ||
+			!slices.Equal(roles[1].Permissions, []string{"records:read", "records:write"}) {
+			t.Fatalf("GetUserRoles returned %+v, want admin and updated doctor", roles)
+		}
+
+		if err := r.RemoveRole(ctx, userID, "admin"); err != nil {
+			t.Fatalf("RemoveRole: %v", err)
+		}
+		if err := r.RemoveRole(ctx, userID, "admin"); !errors.Is(err, repo.ErrNotFound) {
+			t.Fatalf("RemoveRole of unassigned role: want ErrNotFound, got %v", err)
+		}
+
+		roles, err = r.GetUserRoles(ctx, userID)
+		if err != nil {
+			t.Fatalf("GetUserRoles: %v", err)
+		}
+		if len(roles) != 1 || roles[0].Name != "doctor" {
+			t.Fatalf("GetUserRoles returned %+v, want only doctor", roles)
+		}
+
+		roles, err = r.GetUserRoles(ctx, newUUID(t))
+		if err != nil {
+			t.Fatalf("GetUserRoles: %v", err)
+		}
+		if len(roles) != 0 {
+			t.Fatalf("GetUserRoles of user without roles returned %+v", roles)
+		}
+	})
+}
+
+func runEvents(t *testing.T, newRepository func(t *testing.T) *repo.Repository) {
+	ctx := context.Background()
+
+	t.Run("UserEvents", func(t *testing.T) {
+		r := newRepository(t)
+		userID := newUUID(t)
+		now := truncate(time.Now())
+
+		login := entity.SecurityEvent{
+			ID:        newUUID(t),
+			UserID:    userID,
+			SessionID: uuid.NullUUID{UUID: newUUID(t), Valid: true},
+			Type:      "login",
+			IP:        net.ParseIP("192.0.2.1").To4(),
+			UserAgent: "repotest",
+			CreatedAt: now.Add(-time.Minute),
+		}
+		logout := entity.SecurityEvent{
+			ID:        newUUID(t),
+			UserID:    userID,
+			Type:      "logout",
+			Details:   "revoked 1 sessions",
+			CreatedAt: now,
+		}
+		other := entity.SecurityEvent{
+			ID:        newUUID(t),
+			UserID:    newUUID(t),
+			Type:      "login",
+			CreatedAt: now,
+		}
+		for _, event := range []entity.SecurityEvent{login, logout, other} {
+			if err := r.CreateEvent(ctx, event); err != nil {
+				t.Fatalf("CreateEvent: %v", err)
+			}
+		}
+		if err := r.CreateEvent(ctx, login); err == nil {
+			t.Fatal("CreateEvent with existing id: want error")
+		}
+
+		events, err := r.GetUserEvents(ctx, userID, 10)
+		if err != nil {
+			t.Fatalf("GetUserEvents: %v", err)
+		}
+		if len(events) != 2 {
+			t.Fatalf("GetUserEvents returned %d events, want 2", len(events))
+		}
+		assertEvent(t, events[0], logout)
+		assertEvent(t, events[1], login)
+
+		events, err = r.GetUserEvents(ctx, userID, 1)
+		if err != nil {
+			t.Fatalf("GetUserEvents: %v", err)
+		}
+		if len(events) != 1 || events[0].ID != logout.ID {
+			t.Fatalf("GetUserEvents with limit 1 returned %+v, want only the latest event", events)
+		}
+	})
+}
+
+func runWebhooks(t *testing.T, newRepository func(t *testing.T) *repo.Repository) {
+	ctx := context.Background()
+
+	t.Run("Deliveries", func(t *testing.T) {
+		r := newRepository(t)
+		now := truncate(time.Now())
+
+		failed := entity.WebhookDelivery{
+			ID:        newUUID(t),
+			Event:     "wrong ip",
+			Message:   "received ip 192.0.2.2, expected 192.0.2.1",
+			URL:       "http://webhook.test/hook",
+			Error:     "connection refused",
+			CreatedAt: now.Add(-time.Minute),
+		}
+		delivered := failed
+		delivered.ID = newUUID(t)
+		delivered.StatusCode = 200
+		delivered.Error = ""
+		delivered.CreatedAt = now
+		for _, delivery := range []entity.WebhookDelivery{failed, delivered} {
+			if err := r.CreateDelivery(ctx, delivery); err != nil {
+				t.Fatalf("CreateDelivery: %v", err)
+			}
+		}
+		if err := r.CreateDelivery(ctx, failed); err == nil {
+			t.Fatal("CreateDelivery with existing id: want error")
+		}
+
+		deliveries, err := r.ListDeliveries(ctx, 10)
+		if err != nil {
+			t.Fatalf("ListDeliveries: %v", err)
+		}
+		if len(deliveries) != 2 {
+			t.Fatalf("ListDeliveries returned %d deliveries, want 2", len(deliveries))
+		}
+		for i, want := range []entity.WebhookDelivery{delivered, failed} {
+			got := deliveries[i]
+			if got.ID != want.ID || got.Event != want.Event || got.Message != want.Message || got.URL != want.URL ||
+				got.StatusCode != want.StatusCode || got.Error != want.Error || !got.CreatedAt.Equal(want.CreatedAt) {
+				t.Fatalf("delivery %+v, want %+v", got, want)
+			}
+		}
+
+		deliveries, err = r.ListDeliveries(ctx, 1)
+		if err != nil {
+			t.Fatalf("ListDeliveries: %v", err)
+		}
+		if len(deliveries) != 1 || deliveries[0].ID != delivered.ID {
+			t.Fatalf("ListDeliveries with limit 1 returned %+v, want only the latest delivery", deliveries)
+		}
+	})
+}
+
+func assertEvent(t *testing.T, got, want entity.SecurityEvent) {
+	t.Helper()
+
+	if got.ID != want.ID || got.UserID != want.UserID || got.SessionID != want.SessionID || got.Type != want.Type ||
+		!got.IP.Equal(want.IP) || got.UserAgent != want.UserAgent || got.Details != want.Details || !got.CreatedAt.Equal(want.CreatedAt) {
+		t.Fatalf("event %+v, want %+v", got, want)
+	}
+}
+
+func newUUID(t *testing.T) uuid.UUID {
+	t.Helper()
+
+	id, err := uuid.DefaultGenerator.NewV4()
+	if err != nil {
+		t.Fatalf("generate uuid: %v", err)
+	}
+	return id
+}
+
+func newSession(t *testing.T) entity.Session {
+	t.Helper()
+
+	return entity.Session{
+		ID:          newUUID(t),
+		UserId:      newUUID(t),
+		RefreshHash: "hash",
+		UserAgent:   "repotest",
+		IP:          net.ParseIP("192.0.2.1").To4(),
+		CreatedAt:   truncate(time.Now()),
+		ExpiresAt:   truncate(time.Now().Add(time.Hour)),
+		Scope:       "openid",
+	}
+}
+
+func newSessionLike(t *testing.T, session entity.Session) entity.Session {
+	t.Helper()
+
+	session.ID = newUUID(t)
+	return session
+}
+
+func mustCreateSession(t *testing.T, r *repo.Repository, session entity.Session) {
+	t.Helper()
+
+	if _, err := r.CreateSession(context.Background(), session); err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+}
+
+func assertSession(t *testing.T, got, want entity.Session) {
+	t.Helper()
+
+	if got.ID != want.ID || got.UserId != want.UserId || got.RefreshHash != want.RefreshHash ||
+		got.UserAgent != want.UserAgent || !got.IP.Equal(want.IP) || !got.CreatedAt.Equal(want.CreatedAt) ||
+		!got.ExpiresAt.Equal(want.ExpiresAt) || got.IsRevorked != want.IsRevorked || got.Scope != want.Scope {
+		t.Fatalf("session %+v, want %+v", got, want)
+	}
+}
+
+// truncate drops precision that databases don't store.
+func truncate(tm time.Time) time.Time {
+	return tm.Truncate(time.Millisecond)
+}
@@ -0,0 +1,274 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/BabyJhon/medods-test-task/internal/entity"
+	"github.com/BabyJhon/medods-test-task/internal/repo"
+	"github.com/gofrs/uuid"
+)
+
+const (
+	sessionColumns = "id, user_id, refresh_hash, user_agent, ip, created_at, expires_at, is_revoked, scope"
+)
+
+type AuthRepo struct {
+	db *sql.DB
+}
+
+func NewAuthRepo(db *sql.DB) *AuthRepo {
+	return &AuthRepo{
+		db: db,
+	}
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanSession(row rowScanner) (entity.Session, error) {
+	var (
+		session entity.Session
+		ip      string
+	)
+	if err := row.Scan(&session.ID, &session.UserId, &session.RefreshHash, &session.UserAgent, &ip, &session.CreatedAt, &session.ExpiresAt, &session.IsRevorked, &session.Scope); err != nil {
+		return entity.Session{}, err
+	}
+
+	var err error
+	session.IP, err = decodeIP(ip)
+	return session, err
+}
+
+func (r *AuthRepo) CreateSession(ctx context.Context, session entity.Session) (uuid.UUID, error) {
+	return createSession(ctx, r.db, session)
+}
+
+func (r *AuthRepo) GetSessionByID(ctx context.Context, sessionID uuid.UUID) (entity.Session, error) {
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE id = ?", sessionColumns, sessionTable)
+
+	session, err := scanSession(r.db.QueryRowContext(ctx, query, sessionID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entity.Session{}, fmt.Errorf("session %w", repo.ErrNotFound)
+		}
+		return entity.Session{}, err
+	}
+
+	return session, nil
+}
+
+func (r *AuthRepo) RevokeToken(ctx context.Context, sessionID uuid.UUID) error {
+	return revokeSession(ctx, r.db, sessionID)
+}
+
+func (r *AuthRepo) GetAllSessions(ctx context.Context) ([]*entity.Session, error) {
+	var sessions []*entity.Session
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE is_revoked = false", sessionColumns, sessionTable)
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, &session)
+	}
+
+	return sessions, rows.Err()
+}
+
+func (r *AuthRepo) GetSessionsByUser(ctx context.Context, userID uuid.UUID) ([]entity.Session, error) {
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE user_id = ? ORDER BY created_at DESC", sessionColumns, sessionTable)
+
+	return querySessions(ctx, r.db, query, userID)
+}
+
+func (r *AuthRepo) FindSessions(ctx context.Context, filter entity.SessionFilter) ([]entity.Session, error) {
+	var (
+		conditions []string
+		args       []any
+	)
+
+	if filter.UserID != uuid.Nil {
+		conditions, args = append(conditions, "user_id = ?"), append(args, filter.UserID)
+	}
+	if filter.IP != nil {
+		conditions, args = append(conditions, "ip = ?"), append(args, encodeIP(filter.IP))
+	}
+	if filter.UserAgent != "" {
+		conditions, args = append(conditions, "instr(lower(user_agent), lower(?)) > 0"), append(args, filter.UserAgent)
+	}
+	if !filter.CreatedFrom.IsZero() {
+		conditions, args = append(conditions, "created_at >= ?"), append(args, filter.CreatedFrom.UTC())
+	}
+	if !filter.CreatedTo.IsZero() {
+		conditions, args = append(conditions, "created_at < ?"), append(args, filter.CreatedTo.UTC())
+	}
+	if filter.ActiveOnly {
+		conditions, args = append(conditions, "is_revoked = false AND expires_at > ?"), append(args, time.Now().UTC())
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM %s", sessionColumns, sessionTable)
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY created_at DESC"
+	if filter.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", filter.Limit)
+	}
+
+	return querySessions(ctx, r.db, query, args...)
+}
+
+func (r *AuthRepo) RevokeSessions(ctx context.Context, ids []uuid.UUID) ([]entity.Session, error) {
+	if len(ids) == 0 {
+		return []entity.Session{}, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ")
+	args := make([]any, 0, len(ids))
+	for _, id := range ids {
+		args = append(args, id)
+	}
+
+	query := fmt.Sprintf("UPDATE %s SET is_revoked = true WHERE id IN (%s) AND is_revoked = false RETURNING %s", sessionTable, placeholders, sessionColumns)
+
+	return querySessions(ctx, r.db, query, args...)
+}
+
+func querySessions(ctx context.Context, db *sql.DB, query string, args ...any) ([]entity.Session, error) {
+	sessions := []entity.Session{}
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+
+	return sessions, rows.Err()
+}
+
+func (r *AuthRepo) RevokeUserSessions(ctx context.Context, userID uuid.UUID) (int64, error) {
+	query := fmt.Sprintf("UPDATE %s SET is_revoked = true WHERE user_id = ? AND is_revoked = false", sessionTable)
+	result, err := r.db.ExecContext(ctx, query, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+func (r *AuthRepo) DeleteExpiredSessions(ctx context.Context, before time.Time) (int64, error) {
+	query := fmt.Sprintf("DELETE FROM %s WHERE expires_at < ?", sessionTable)
+	result, err := r.db.ExecContext(ctx, query, before.UTC())
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+func (r *AuthRepo) RefreshTokens(ctx context.Context, oldSession, newSession entity.Session) (uuid.UUID, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	if err := revokeSession(ctx, tx, oldSession.ID); err != nil {
+		tx.Rollback()
+		return uuid.Nil, err
+	}
+
+	id, err := createSession(ctx, tx, newSession)
+	if err != nil {
+		tx.Rollback()
+		return id, err
+	}
+
+	return id, tx.Commit()
+}
+
+// execer is implemented by both *sql.DB and *sql.Tx.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func createSession(ctx context.Context, db execer, session entity.Session) (uuid.UUID, error) {
+	var id uuid.UUID
+	query := fmt.Sprintf("INSERT INTO %s (%s) values (?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id", sessionTable, sessionColumns)
+
+	row := db.QueryRowContext(ctx, query, session.ID, session.UserId, session.RefreshHash, session.UserAgent, encodeIP(session.IP), session.CreatedAt.UTC(), session.ExpiresAt.UTC(), session.IsRevorked, session.Scope)
+	if err := row.Scan(&id); err != nil {
+		return id, err
+	}
+
+	return id, nil
+}
+
+func revokeSession(ctx context.Context, db execer, sessionID uuid.UUID) error {
+	query := fmt.Sprintf("UPDATE %s SET is_revoked = true WHERE id = ? AND is_revoked = false", sessionTable)
+	result, err := db.ExecContext(ctx, query, sessionID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return fmt.Errorf("session %w or already revoked", repo.ErrNotFound)
+	}
+
+	return nil
+}
+
+func (r *AuthRepo) ListUsers(ctx context.Context, limit int) ([]entity.UserSummary, error) {
+	users := []entity.UserSummary{}
+	query := fmt.Sprintf("SELECT user_id, COUNT(*), SUM(CASE WHEN is_revoked = false AND expires_at > ? THEN 1 ELSE 0 END), MAX(created_at) FROM %s GROUP BY user_id ORDER BY MAX(created_at) DESC", sessionTable)
+	if limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", limit)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			user        entity.UserSummary
+			lastLoginAt string
+		)
+		if err := rows.Scan(&user.UserID, &user.Sessions, &user.ActiveSessions, &lastLoginAt); err != nil {
+			return nil, err
+		}
+		if user.LastLoginAt, err = decodeTime(lastLoginAt); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
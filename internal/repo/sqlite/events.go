@@ -0,0 +1,60 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net"
+
+	"github.com/BabyJhon/medods-test-task/internal/entity"
+	"github.com/gofrs/uuid"
+)
+
+type EventsRepo struct {
+	db *sql.DB
+}
+
+func NewEventsRepo(db *sql.DB) *EventsRepo {
+	return &EventsRepo{
+		db: db,
+	}
+}
+
+func (r *EventsRepo) CreateEvent(ctx context.Context, event entity.SecurityEvent) error {
+	query := fmt.Sprintf("INSERT INTO %s (id, user_id, session_id, type, ip, user_agent, details, created_at) values (?, ?, ?, ?, ?, ?, ?, ?)", securityEventTable)
+
+	var ip sql.NullString
+	if event.IP != nil {
+		ip = sql.NullString{String: encodeIP(event.IP), Valid: true}
+	}
+
+	_, err := r.db.ExecContext(ctx, query, event.ID, event.UserID, event.SessionID, event.Type, ip, event.UserAgent, event.Details, event.CreatedAt.UTC())
+	return err
+}
+
+func (r *EventsRepo) GetUserEvents(ctx context.Context, userID uuid.UUID, limit int) ([]entity.SecurityEvent, error) {
+	events := []entity.SecurityEvent{}
+	query := fmt.Sprintf("SELECT id, user_id, session_id, type, ip, user_agent, details, created_at FROM %s WHERE user_id = ? ORDER BY created_at DESC LIMIT ?", securityEventTable)
+
+	rows, err := r.db.QueryContext(ctx, query, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			event entity.SecurityEvent
+			ip    sql.NullString
+		)
+		if err := rows.Scan(&event.ID, &event.UserID, &event.SessionID, &event.Type, &ip, &event.UserAgent, &event.Details, &event.CreatedAt); err != nil {
+			return nil, err
+		}
+		if ip.Valid {
+			event.IP = net.ParseIP(ip.String)
+		}
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
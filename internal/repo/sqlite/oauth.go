@@ -0,0 +1,124 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/BabyJhon/medods-test-task/internal/entity"
+	"github.com/BabyJhon/medods-test-task/internal/repo"
+)
+
+type OAuthRepo struct {
+	db *sql.DB
+}
+
+func NewOAuthRepo(db *sql.DB) *OAuthRepo {
+	return &OAuthRepo{
+		db: db,
+	}
+}
+
+func (r *OAuthRepo) CreateAuthorizationCode(ctx context.Context, code entity.AuthorizationCode) error {
+	query := fmt.Sprintf("INSERT INTO %s (code_hash, client_id, user_id, redirect_uri, code_challenge, code_challenge_method, scope, nonce, auth_time, created_at, expires_at, is_used) values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)", authorizationCodeTable)
+
+	_, err := r.db.ExecContext(ctx, query, code.CodeHash, code.ClientID, code.UserID, code.RedirectURI, code.CodeChallenge, code.CodeChallengeMethod, code.Scope, code.Nonce, code.AuthTime.UTC(), code.CreatedAt.UTC(), code.ExpiresAt.UTC(), code.IsUsed)
+	return err
+}
+
+func (r *OAuthRepo) UseAuthorizationCode(ctx context.Context, codeHash string) (entity.AuthorizationCode, error) {
+	var code entity.AuthorizationCode
+
+	query := fmt.Sprintf("UPDATE %s SET is_used = true WHERE code_hash = ? AND is_used = false RETURNING code_hash, client_id, user_id, redirect_uri, code_challenge, code_challenge_method, scope, nonce, auth_time, created_at, expires_at, is_used", authorizationCodeTable)
+
+	row := r.db.QueryRowContext(ctx, query, codeHash)
+	if err := row.Scan(&code.CodeHash, &code.ClientID, &code.UserID, &code.RedirectURI, &code.CodeChallenge, &code.CodeChallengeMethod, &code.Scope, &code.Nonce, &code.AuthTime, &code.CreatedAt, &code.ExpiresAt, &code.IsUsed); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entity.AuthorizationCode{}, fmt.Errorf("authorization code %w or already used", repo.ErrNotFound)
+		}
+		return entity.AuthorizationCode{}, err
+	}
+
+	return code, nil
+}
+
+func (r *OAuthRepo) CreateClient(ctx context.Context, client entity.OAuthClient) error {
+	grantTypes, err := encodeStrings(client.GrantTypes)
+	if err != nil {
+		return err
+	}
+	scopes, err := encodeStrings(client.Scopes)
+	if err != nil {
+		return err
+	}
+	redirectURIs, err := encodeStrings(client.RedirectURIs)
+	if err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s (client_id, secret_hash, name, grant_types, scopes, redirect_uris, is_public, is_disabled, dpop_bound_access_tokens, created_at, updated_at) values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)", oauthClientTable)
+
+	_, err = r.db.ExecContext(ctx, query, client.ClientID, client.SecretHash, client.Name, grantTypes, scopes, redirectURIs, client.IsPublic, client.IsDisabled, client.DPoPBoundAccessTokens, client.CreatedAt.UTC(), client.UpdatedAt.UTC())
+	return err
+}
+
+func (r *OAuthRepo) GetClientByID(ctx context.Context, clientID string) (entity.OAuthClient, error) {
+	var (
+		client                           entity.OAuthClient
+		grantTypes, scopes, redirectURIs string
+	)
+
+	query := fmt.Sprintf("SELECT client_id, secret_hash, name, grant_types, scopes, redirect_uris, is_public, is_disabled, dpop_bound_access_tokens, created_at, updated_at FROM %s WHERE client_id = ?", oauthClientTable)
+
+	row := r.db.QueryRowContext(ctx, query, clientID)
+	if err := row.Scan(&client.ClientID, &client.SecretHash, &client.Name, &grantTypes, &scopes, &redirectURIs, &client.IsPublic, &client.IsDisabled, &client.DPoPBoundAccessTokens, &client.CreatedAt, &client.UpdatedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entity.OAuthClient{}, fmt.Errorf("client %w", repo.ErrNotFound)
+		}
+		return entity.OAuthClient{}, err
+	}
+
+	var err error
+	if client.GrantTypes, err = decodeStrings(grantTypes); err != nil {
+		return entity.OAuthClient{}, err
+	}
+	if client.Scopes, err = decodeStrings(scopes); err != nil {
+		return entity.OAuthClient{}, err
+	}
+	if client.RedirectURIs, err = decodeStrings(redirectURIs); err != nil {
+		return entity.OAuthClient{}, err
+	}
+
+	return client, nil
+}
+
+func (r *OAuthRepo) UpdateClientSecret(ctx context.Context, clientID, secretHash string) error {
+	query := fmt.Sprintf("UPDATE %s SET secret_hash = ?, updated_at = ? WHERE client_id = ?", oauthClientTable)
+
+	return r.updateClient(ctx, query, secretHash, clientID)
+}
+
+func (r *OAuthRepo) SetClientDisabled(ctx context.Context, clientID string, disabled bool) error {
+	query := fmt.Sprintf("UPDATE %s SET is_disabled = ?, updated_at = ? WHERE client_id = ?", oauthClientTable)
+
+	return r.updateClient(ctx, query, disabled, clientID)
+}
+
+func (r *OAuthRepo) updateClient(ctx context.Context, query string, value any, clientID string) error {
+	result, err := r.db.ExecContext(ctx, query, value, time.Now().UTC(), clientID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return fmt.Errorf("client %w", repo.ErrNotFound)
+	}
+
+	return nil
+}
@@ -0,0 +1,110 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/BabyJhon/medods-test-task/internal/entity"
+	"github.com/BabyJhon/medods-test-task/internal/repo"
+	"github.com/gofrs/uuid"
+)
+
+type RBACRepo struct {
+	db *sql.DB
+}
+
+func NewRBACRepo(db *sql.DB) *RBACRepo {
+	return &RBACRepo{
+		db: db,
+	}
+}
+
+func (r *RBACRepo) GetUserRoles(ctx context.Context, userID uuid.UUID) ([]entity.Role, error) {
+	query := fmt.Sprintf("SELECT r.name, r.permissions, r.created_at FROM %s r JOIN %s ur ON ur.role = r.name WHERE ur.user_id = ? ORDER BY r.name", roleTable, userRoleTable)
+
+	return r.queryRoles(ctx, query, userID)
+}
+
+func (r *RBACRepo) ListRoles(ctx context.Context) ([]entity.Role, error) {
+	query := fmt.Sprintf("SELECT name, permissions, created_at FROM %s ORDER BY name", roleTable)
+
+	return r.queryRoles(ctx, query)
+}
+
+func (r *RBACRepo) UpsertRole(ctx context.Context, role entity.Role) error {
+	permissions, err := encodeStrings(role.Permissions)
+	if err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s (name, permissions, created_at) values (?, ?, ?) ON CONFLICT (name) DO UPDATE SET permissions = excluded.permissions", roleTable)
+
+	_, err = r.db.ExecContext(ctx, query, role.Name, permissions, role.CreatedAt.UTC())
+	return err
+}
+
+func (r *RBACRepo) AssignRole(ctx context.Context, userID uuid.UUID, role string) error {
+	query := fmt.Sprintf("INSERT INTO %s (user_id, role, created_at) SELECT ?, name, ? FROM %s WHERE name = ? ON CONFLICT DO NOTHING", userRoleTable, roleTable)
+
+	result, err := r.db.ExecContext(ctx, query, userID, time.Now().UTC(), role)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return fmt.Errorf("role %w or already assigned", repo.ErrNotFound)
+	}
+
+	return nil
+}
+
+func (r *RBACRepo) RemoveRole(ctx context.Context, userID uuid.UUID, role string) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE user_id = ? AND role = ?", userRoleTable)
+
+	result, err := r.db.ExecContext(ctx, query, userID, role)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return fmt.Errorf("role assignment %w", repo.ErrNotFound)
+	}
+
+	return nil
+}
+
+func (r *RBACRepo) queryRoles(ctx context.Context, query string, args ...any) ([]entity.Role, error) {
+	roles := []entity.Role{}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			role        entity.Role
+			permissions string
+		)
+		if err := rows.Scan(&role.Name, &permissions, &role.CreatedAt); err != nil {
+			return nil, err
+		}
+		if role.Permissions, err = decodeStrings(permissions); err != nil {
+			return nil, err
+		}
+		roles = append(roles, role)
+	}
+
+	return roles, rows.Err()
+}
@@ -0,0 +1,127 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"time"
+
+	"github.com/BabyJhon/medods-test-task/internal/repo"
+	_ "modernc.org/sqlite"
+)
+
+const (
+	sessionTable           = "refresh_sessions"
+	authorizationCodeTable = "authorization_codes"
+	oauthClientTable       = "oauth_clients"
+	roleTable              = "roles"
+	userRoleTable          = "user_roles"
+	securityEventTable     = "security_events"
+	webhookDeliveryTable   = "webhook_deliveries"
+)
+
+type Config struct {
+	Path        string
+	BusyTimeout time.Duration
+}
+
+// NewSQLite opens the database file in WAL mode with foreign keys enabled. SQLite allows
+// only one writer at a time, so the pool is limited to a single connection and concurrent
+// requests queue up in database/sql instead of failing with SQLITE_BUSY.
+func NewSQLite(ctx context.Context, cfg Config) (*sql.DB, error) {
+	busyTimeout := cfg.BusyTimeout
+	if busyTimeout == 0 {
+		busyTimeout = 5 * time.Second
+	}
+
+	params := url.Values{}
+	// busy_timeout идет первым, чтобы остальные pragma тоже ждали блокировку.
+	params.Add("_pragma", fmt.Sprintf("busy_timeout(%d)", busyTimeout.Milliseconds()))
+	params.Add("_pragma", "journal_mode(WAL)")
+	params.Add("_pragma", "synchronous(NORMAL)")
+	params.Add("_pragma", "foreign_keys(1)")
+	params.Set("_txlock", "immediate")
+	// Время пишется как "2006-01-02 15:04:05.999999999-07:00", первый из timeLayouts.
+	params.Set("_time_format", "sqlite")
+
+	db, err := sql.Open("sqlite", "file:"+cfg.Path+"?"+params.Encode())
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+func NewRepository(db *sql.DB) *repo.Repository {
+	return &repo.Repository{
+		Auth:     NewAuthRepo(db),
+		OAuth:    NewOAuthRepo(db),
+		RBAC:     NewRBACRepo(db),
+		Events:   NewEventsRepo(db),
+		Webhooks: NewWebhooksRepo(db),
+	}
+}
+
+// SQLite has no INET, UUID, array or timestamptz types. UUIDs are stored as text through
+// uuid.UUID's driver.Valuer, IPs as their text form, arrays as JSON and times in UTC,
+// so string comparison of timestamps keeps their order.
+
+func encodeStrings(values []string) (string, error) {
+	if values == nil {
+		values = []string{}
+	}
+	data, err := json.Marshal(values)
+	return string(data), err
+}
+
+func decodeStrings(data string) ([]string, error) {
+	values := []string{}
+	if err := json.Unmarshal([]byte(data), &values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+func encodeIP(ip net.IP) string {
+	return ip.String()
+}
+
+func decodeIP(data string) (net.IP, error) {
+	ip := net.ParseIP(data)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid ip %q", data)
+	}
+	return ip, nil
+}
+
+// timeLayouts are the text forms of times in the database, the layout times are written
+// in first.
+var timeLayouts = []string{
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02T15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+// decodeTime parses a time returned by an aggregate like MAX. The driver converts only
+// columns declared as TIMESTAMP, aggregates come back as text.
+func decodeTime(data string) (time.Time, error) {
+	for _, layout := range timeLayouts {
+		if tm, err := time.ParseInLocation(layout, data, time.UTC); err == nil {
+			return tm, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q", data)
+}
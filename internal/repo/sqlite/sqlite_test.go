@@ -0,0 +1,34 @@
+package sqlite_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/BabyJhon/medods-test-task/internal/migrate"
+	"github.com/BabyJhon/medods-test-task/internal/repo"
+	"github.com/BabyJhon/medods-test-task/internal/repo/repotest"
+	"github.com/BabyJhon/medods-test-task/internal/repo/sqlite"
+)
+
+func TestConformance(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) *repo.Repository {
+		ctx := context.Background()
+
+		db, err := sqlite.NewSQLite(ctx, sqlite.Config{Path: filepath.Join(t.TempDir(), "auth.db")})
+		if err != nil {
+			t.Fatalf("NewSQLite: %v", err)
+		}
+		t.Cleanup(func() { db.Close() })
+
+		migrator, err := migrate.NewSQLite(db)
+		if err != nil {
+			t.Fatalf("migrate.NewSQLite: %v", err)
+		}
+		if _, err := migrator.Up(ctx); err != nil {
+			t.Fatalf("migrate up: %v", err)
+		}
+
+		return sqlite.NewRepository(db)
+	})
+}
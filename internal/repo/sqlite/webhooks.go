@@ -0,0 +1,47 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/BabyJhon/medods-test-task/internal/entity"
+)
+
+type WebhooksRepo struct {
+	db *sql.DB
+}
+
+func NewWebhooksRepo(db *sql.DB) *WebhooksRepo {
+	return &WebhooksRepo{
+		db: db,
+	}
+}
+
+func (r *WebhooksRepo) CreateDelivery(ctx context.Context, delivery entity.WebhookDelivery) error {
+	query := fmt.Sprintf("INSERT INTO %s (id, event, message, url, status_code, error, created_at) values (?, ?, ?, ?, ?, ?, ?)", webhookDeliveryTable)
+
+	_, err := r.db.ExecContext(ctx, query, delivery.ID, delivery.Event, delivery.Message, delivery.URL, delivery.StatusCode, delivery.Error, delivery.CreatedAt.UTC())
+	return err
+}
+
+func (r *WebhooksRepo) ListDeliveries(ctx context.Context, limit int) ([]entity.WebhookDelivery, error) {
+	deliveries := []entity.WebhookDelivery{}
+	query := fmt.Sprintf("SELECT id, event, message, url, status_code, error, created_at FROM %s ORDER BY created_at DESC LIMIT ?", webhookDeliveryTable)
+
+	rows, err := r.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var delivery entity.WebhookDelivery
+		if err := rows.Scan(&delivery.ID, &delivery.Event, &delivery.Message, &delivery.URL, &delivery.StatusCode, &delivery.Error, &delivery.CreatedAt); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, rows.Err()
+}
@@ -0,0 +1,48 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/BabyJhon/medods-test-task/internal/entity"
+	"github.com/BabyJhon/medods-test-task/pkg/postgres"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type WebhooksRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewWebhooksRepo(db *pgxpool.Pool) *WebhooksRepo {
+	return &WebhooksRepo{
+		db: db,
+	}
+}
+
+func (r *WebhooksRepo) CreateDelivery(ctx context.Context, delivery entity.WebhookDelivery) error {
+	query := fmt.Sprintf("INSERT INTO %s (id, event, message, url, status_code, error, created_at) values ($1, $2, $3, $4, $5, $6, $7)", postgres.WebhookDeliveryTable)
+
+	_, err := r.db.Exec(ctx, query, delivery.ID, delivery.Event, delivery.Message, delivery.URL, delivery.StatusCode, delivery.Error, delivery.CreatedAt)
+	return err
+}
+
+func (r *WebhooksRepo) ListDeliveries(ctx context.Context, limit int) ([]entity.WebhookDelivery, error) {
+	deliveries := []entity.WebhookDelivery{}
+	query := fmt.Sprintf("SELECT id, event, message, url, status_code, error, created_at FROM %s ORDER BY created_at DESC LIMIT $1", postgres.WebhookDeliveryTable)
+
+	rows, err := r.db.Query(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var delivery entity.WebhookDelivery
+		if err := rows.Scan(&delivery.ID, &delivery.Event, &delivery.Message, &delivery.URL, &delivery.StatusCode, &delivery.Error, &delivery.CreatedAt); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, rows.Err()
+}
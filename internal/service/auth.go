@@ -1,17 +1,20 @@
 package service
 
 import (
-	"bytes"
 	"context"
 	"crypto/rand"
+	"crypto/subtle"
 	"encoding/base64"
 	"errors"
 	"fmt"
 	"net"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/BabyJhon/medods-test-task/internal/entity"
+	"github.com/BabyJhon/medods-test-task/internal/i18n"
+	"github.com/BabyJhon/medods-test-task/internal/logging"
 	"github.com/BabyJhon/medods-test-task/internal/repo"
 	"github.com/gofrs/uuid"
 	"github.com/golang-jwt/jwt/v5"
@@ -20,31 +23,60 @@ import (
 
 const (
 	bcryptCost      = 10
-	accessTokenTTL  = 15 * time.Minute
-	refreshTokenTTL = 48 * time.Hour
+	AccessTokenTTL  = 15 * time.Minute
+	RefreshTokenTTL = 48 * time.Hour
+
+	// AccessTokenType is the typ header of access tokens (RFC 9068). Access and ID tokens
+	// are signed with the same keys, so it is what tells them apart.
+	AccessTokenType = "at+jwt"
+)
+
+var (
+	ErrCertificateMismatch = errors.New("token is bound to another client certificate")
 )
 
 type AuthService struct {
-	repo repo.Auth
+	repo     repo.Auth
+	rbac     repo.RBAC
+	events   repo.Events
+	webhooks Webhooks
+	// keys[0] signs access tokens, all keys are published in JWKS.
+	keys []*SigningKey
+	// webhookMessages describes webhook events in the language of their receiver.
+	webhookMessages i18n.Translator
 }
 
-func NewAuthService(repo repo.Auth) *AuthService {
+func NewAuthService(repo repo.Auth, rbac repo.RBAC, events repo.Events, webhooks Webhooks, keys []*SigningKey, webhookMessages i18n.Translator) *AuthService {
 	return &AuthService{
-		repo: repo,
+		repo:            repo,
+		rbac:            rbac,
+		events:          events,
+		webhooks:        webhooks,
+		keys:            keys,
+		webhookMessages: webhookMessages,
 	}
 }
 
-func (s *AuthService) generateAccessToken(userID, sessionID uuid.UUID, userAgent string, clientIP net.IP) (string, error) {
-	accessToken := jwt.NewWithClaims(jwt.SigningMethodHS512, entity.Claimes{
-		SessionID: sessionID,
-		UserAgent: userAgent,
-		IP:        clientIP,
+// SignAccessToken signs access token claims with the current key, so resource servers
+// verify them with the JWKS instead of sharing a secret that could mint tokens.
+func (s *AuthService) SignAccessToken(claims entity.Claimes) (string, error) {
+	return signJWT(s.keys, claims, AccessTokenType)
+}
+
+func (s *AuthService) generateAccessToken(userID, sessionID uuid.UUID, userAgent string, clientIP net.IP, scope string, roles []string, cnf *entity.Confirmation) (string, error) {
+	return s.SignAccessToken(entity.Claimes{
+		SessionID:    sessionID,
+		UserAgent:    userAgent,
+		IP:           clientIP,
+		Scope:        scope,
+		Roles:        roles,
+		Confirmation: cnf,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(accessTokenTTL)),
+			Subject:   userID.String(),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(AccessTokenTTL)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	})
-	return accessToken.SignedString([]byte(os.Getenv("SIGNING_KEY")))
 }
 
 func (s *AuthService) generateRefreshToken() ([]byte, error) {
@@ -57,24 +89,51 @@ func (s *AuthService) generateRefreshToken() ([]byte, error) {
 	return tokenBytes, nil
 }
 
-func (s *AuthService) CreateTokens(ctx context.Context, guid uuid.UUID, userAgent string, clientIP net.IP) (string, string, error) {
+// CreateTokens issues a new session. A non-nil cnf binds the access token to the client
+// certificate and DPoP key of the request. The user is identified only by the GUID, so the
+// admin scope is never granted here.
+func (s *AuthService) CreateTokens(ctx context.Context, guid uuid.UUID, userAgent string, clientIP net.IP, scope string, cnf *entity.Confirmation) (entity.TokenResponse, error) {
+	return s.createTokens(ctx, guid, userAgent, clientIP, scope, cnf, false)
+}
+
+// CreateAdminTokens issues a session with the admin scope for a user with the admin role.
+// It is meant for operator tools that already have access to the storage and signing keys
+// and is not exposed over HTTP.
+func (s *AuthService) CreateAdminTokens(ctx context.Context, guid uuid.UUID, userAgent string) (entity.TokenResponse, error) {
+	scope, _, err := grantScopes(ctx, s.rbac, guid, ScopeAdmin, true)
+	if err != nil {
+		return entity.TokenResponse{}, err
+	}
+	if scope != ScopeAdmin {
+		return entity.TokenResponse{}, fmt.Errorf("%w: user %s has no role with the %s permission", ErrInvalidScope, guid, ScopeAdmin)
+	}
+
+	return s.createTokens(ctx, guid, userAgent, nil, scope, nil, true)
+}
+
+func (s *AuthService) createTokens(ctx context.Context, guid uuid.UUID, userAgent string, clientIP net.IP, scope string, cnf *entity.Confirmation, allowAdmin bool) (entity.TokenResponse, error) {
 	refreshTokenBytes, err := s.generateRefreshToken()
 	if err != nil {
-		return "", "", err
+		return entity.TokenResponse{}, err
 	}
 
 	BcryptBytes, err := bcrypt.GenerateFromPassword(refreshTokenBytes, bcrypt.DefaultCost)
 	if err != nil {
-		return "", "", err
+		return entity.TokenResponse{}, err
+	}
+
+	grantedScope, roles, err := grantScopes(ctx, s.rbac, guid, scope, allowAdmin)
+	if err != nil {
+		return entity.TokenResponse{}, err
 	}
 
 	sessionID, err := uuid.DefaultGenerator.NewV4()
 	if err != nil {
-		return "", "", err
+		return entity.TokenResponse{}, err
 	}
-	accessToken, err := s.generateAccessToken(guid, sessionID, userAgent, clientIP)
+	accessToken, err := s.generateAccessToken(guid, sessionID, userAgent, clientIP, grantedScope, roles, cnf)
 	if err != nil {
-		return "", "", err
+		return entity.TokenResponse{}, err
 	}
 
 	session := entity.Session{
@@ -84,27 +143,39 @@ func (s *AuthService) CreateTokens(ctx context.Context, guid uuid.UUID, userAgen
 		UserAgent:   userAgent,
 		IP:          clientIP,
 		CreatedAt:   time.Now(),
-		ExpiresAt:   time.Now().Add(refreshTokenTTL),
+		ExpiresAt:   time.Now().Add(RefreshTokenTTL),
 		IsRevorked:  false,
+		Scope:       grantedScope,
 	}
 	_, err = s.repo.CreateSession(ctx, session)
 	if err != nil {
-		return "", "", err
+		return entity.TokenResponse{}, err
 	}
+	recordEvent(ctx, s.events, EventLogin, session, clientIP, userAgent, "")
 
 	refreshToken := base64.RawURLEncoding.EncodeToString(refreshTokenBytes)
-	return accessToken, refreshToken, nil
+	return newTokenResponse(accessToken, refreshToken, grantedScope, cnf), nil
 }
 
 func (a *AuthService) Parsetoken(accessToken string) (*entity.Claimes, error) {
-	token, err := jwt.ParseWithClaims(accessToken, &entity.Claimes{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("invalid signing method")
-		}
-		return []byte(os.Getenv("SIGNING_KEY")), nil
-	})
+	return a.parseToken(accessToken)
+}
+
+// ParseExpiredToken verifies the signature of the access token but accepts it after expiration.
+// It is used by refresh, where the refresh token is the credential and the access token only
+// proves that both tokens were issued together.
+func (a *AuthService) ParseExpiredToken(accessToken string) (*entity.Claimes, error) {
+	return a.parseToken(accessToken, jwt.WithoutClaimsValidation())
+}
+
+func (a *AuthService) parseToken(accessToken string, options ...jwt.ParserOption) (*entity.Claimes, error) {
+	options = append(options, jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodHS512.Alg()}))
+	token, err := jwt.ParseWithClaims(accessToken, &entity.Claimes{}, a.accessTokenKey, options...)
+	if errors.Is(err, jwt.ErrTokenExpired) {
+		return nil, ErrTokenExpired
+	}
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
 	}
 
 	claims, ok := token.Claims.(*entity.Claimes)
@@ -115,13 +186,34 @@ func (a *AuthService) Parsetoken(accessToken string) (*entity.Claimes, error) {
 	return claims, nil
 }
 
+func (a *AuthService) accessTokenKey(token *jwt.Token) (interface{}, error) {
+	// До перехода на RS256 access токены подписывались HS512 ключом SIGNING_KEY. Пока он
+	// задан, такие токены принимаются, чтобы выданные до обновления сессии можно было обновить.
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+		legacyKey := os.Getenv("SIGNING_KEY")
+		if legacyKey == "" {
+			return nil, errors.New("HS512 access tokens are no longer accepted")
+		}
+		return []byte(legacyKey), nil
+	}
+
+	// ID токены подписываются тем же ключом, поэтому typ обязателен.
+	if typ, _ := token.Header["typ"].(string); !strings.EqualFold(typ, AccessTokenType) {
+		return nil, fmt.Errorf("typ must be %s, got %q", AccessTokenType, typ)
+	}
+	return publicKey(a.keys, token)
+}
+
 func (a *AuthService) GetSession(ctx context.Context, token entity.Claimes) (entity.Session, error) {
 	session, err := a.repo.GetSessionByID(ctx, token.SessionID)
+	if errors.Is(err, ErrNotFound) {
+		return entity.Session{}, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
 	if err != nil {
 		return entity.Session{}, err
 	}
 	if session.IsRevorked {
-		return entity.Session{}, errors.New("token is revoked")
+		return entity.Session{}, ErrSessionRevoked
 	}
 
 	return session, nil
@@ -129,18 +221,32 @@ func (a *AuthService) GetSession(ctx context.Context, token entity.Claimes) (ent
 
 func (a *AuthService) RevokeToken(ctx context.Context, sessionID uuid.UUID) error {
 	err := a.repo.RevokeToken(ctx, sessionID)
-	return err
+	if err != nil {
+		return err
+	}
+
+	if session, err := a.repo.GetSessionByID(ctx, sessionID); err == nil {
+		recordEvent(ctx, a.events, EventSessionRevoked, session, nil, "", "")
+	}
+	return nil
 }
 
-func (a *AuthService) RefreshTokens(ctx context.Context, accessToken entity.Claimes, base64RefreshToken string, userAgent string, IP net.IP) (string, string, error) {
+func (a *AuthService) RefreshTokens(ctx context.Context, accessToken entity.Claimes, base64RefreshToken string, userAgent string, IP net.IP, cnf *entity.Confirmation) (entity.TokenResponse, error) {
+	// Токены, привязанные к сертификату или DPoP ключу, обновляются только с тем же
+	// сертификатом и ключом. Refresh токен хранится в сессии без cnf: он принимается только
+	// вместе с access токеном своей сессии, поэтому привязка access токена распространяется
+	// и на него.
+	if err := VerifyConfirmation(&accessToken, cnf); err != nil {
+		return entity.TokenResponse{}, err
+	}
 
 	decodedRefreshToken, err := base64.RawURLEncoding.DecodeString(base64RefreshToken)
 	if err != nil {
-		return "", "", err
+		return entity.TokenResponse{}, fmt.Errorf("%w: refresh token: %w", ErrInvalidToken, err)
 	}
 	sessions, err := a.repo.GetAllSessions(ctx)
 	if err != nil {
-		return "", "", err
+		return entity.TokenResponse{}, err
 	}
 	var session entity.Session
 	for i := 0; i < len(sessions); i++ {
@@ -149,56 +255,54 @@ func (a *AuthService) RefreshTokens(ctx context.Context, accessToken entity.Clai
 		}
 	}
 
+	if session.ID == uuid.Nil {
+		return entity.TokenResponse{}, a.unknownRefreshToken(ctx, accessToken, decodedRefreshToken)
+	}
+
 	if session.ExpiresAt.Before(time.Now()) {
-		return "", "", errors.New("refresh token is expired")
+		return entity.TokenResponse{}, fmt.Errorf("%w: refresh token", ErrTokenExpired)
 	}
 
 	if session.ID != accessToken.SessionID {
-		return "", "", errors.New("the tokens were not created together")
+		return entity.TokenResponse{}, ErrTokensMismatch
 	}
 
 	if userAgent != session.UserAgent || userAgent != accessToken.UserAgent {
 		err := a.repo.RevokeToken(ctx, session.ID)
 		if err != nil {
-			return "", "", err
+			return entity.TokenResponse{}, err
 		}
-		return "", "", errors.New("user-agent has been changed")
+		recordEvent(ctx, a.events, EventUserAgentChanged, session, IP, userAgent, fmt.Sprintf("expected %q", session.UserAgent))
+		return entity.TokenResponse{}, ErrUserAgentChanged
 	}
 
-	if bytes.Equal(IP, session.IP) {
-		message := fmt.Sprintf("received ip %s, expected %s", IP, session.IP)
-		err := SendWebhook(WebhookPayload{Event: "wrong ip",
-			Message: message,
-		})
-		if err != nil {
-			return "", "", err
-		}
-	}
-	if bytes.Equal(IP, accessToken.IP) {
-		message := fmt.Sprintf("received ip %s, expected %s", IP, accessToken.IP)
-		err := SendWebhook(WebhookPayload{Event: "wrong ip",
-			Message: message,
-		})
-		if err != nil {
-			return "", "", err
-		}
+	if !IP.Equal(session.IP) {
+		recordEvent(ctx, a.events, EventIPChanged, session, IP, userAgent, fmt.Sprintf("expected %s", session.IP))
+		a.sendWrongIPWebhook(ctx, IP, session.IP)
+	} else if !IP.Equal(accessToken.IP) {
+		a.sendWrongIPWebhook(ctx, IP, accessToken.IP)
 	}
 
 	newSessionID, err := uuid.DefaultGenerator.NewV4()
 	if err != nil {
-		return "", "", err
+		return entity.TokenResponse{}, err
 	}
-	newAccessToken, err := a.generateAccessToken(session.UserId, newSessionID, userAgent, IP)
+	// Refresh doesn't prove more than the GUID did, so the admin scope isn't renewed.
+	grantedScope, roles, err := grantScopes(ctx, a.rbac, session.UserId, session.Scope, false)
 	if err != nil {
-		return "", "", err
+		return entity.TokenResponse{}, err
+	}
+	newAccessToken, err := a.generateAccessToken(session.UserId, newSessionID, userAgent, IP, grantedScope, roles, cnf)
+	if err != nil {
+		return entity.TokenResponse{}, err
 	}
 	newRefreshTokenBytes, err := a.generateRefreshToken()
 	if err != nil {
-		return "", "", err
+		return entity.TokenResponse{}, err
 	}
 	newBcryptBytes, err := bcrypt.GenerateFromPassword(newRefreshTokenBytes, bcrypt.DefaultCost)
 	if err != nil {
-		return "", "", err
+		return entity.TokenResponse{}, err
 	}
 	newSession := entity.Session{
 		ID:          newSessionID,
@@ -207,13 +311,80 @@ func (a *AuthService) RefreshTokens(ctx context.Context, accessToken entity.Clai
 		UserAgent:   userAgent,
 		IP:          IP,
 		CreatedAt:   time.Now(),
-		ExpiresAt:   time.Now().Add(refreshTokenTTL),
+		ExpiresAt:   time.Now().Add(RefreshTokenTTL),
 		IsRevorked:  false,
+		Scope:       grantedScope,
 	}
 	_, err = a.repo.RefreshTokens(ctx, session, newSession)
+	// Сессию успели отозвать, пока выпускались новые токены: этот refresh токен уже обменян.
+	if errors.Is(err, ErrNotFound) {
+		return entity.TokenResponse{}, fmt.Errorf("%w: %w", ErrRefreshReuse, err)
+	}
 	if err != nil {
-		return "", "", err
+		return entity.TokenResponse{}, err
 	}
+	recordEvent(ctx, a.events, EventRefresh, newSession, IP, userAgent, fmt.Sprintf("replaces session %s", session.ID))
 	newRefreshToken := base64.RawURLEncoding.EncodeToString(newRefreshTokenBytes)
-	return newAccessToken, newRefreshToken, nil
+	return newTokenResponse(newAccessToken, newRefreshToken, grantedScope, cnf), nil
+}
+
+// sendWrongIPWebhook only logs errors: a new IP is not a reason to refuse the refresh,
+// and the failed delivery is recorded for the admin console.
+func (a *AuthService) sendWrongIPWebhook(ctx context.Context, IP, expected net.IP) {
+	message := a.webhookMessages.Message("webhook.wrong_ip", IP, expected)
+	err := a.webhooks.SendWebhook(ctx, WebhookPayload{Event: "wrong ip",
+		Message: message,
+	})
+	if err != nil {
+		logging.FromContext(ctx).Errorf("failed to send wrong ip webhook: %s", err.Error())
+	}
+}
+
+// unknownRefreshToken explains why the refresh token matches no active session. A token
+// that matches the revoked session of its own access token was valid once and is presented
+// again after refresh or logout revoked it, which is reported as reuse.
+func (a *AuthService) unknownRefreshToken(ctx context.Context, accessToken entity.Claimes, refreshToken []byte) error {
+	session, err := a.repo.GetSessionByID(ctx, accessToken.SessionID)
+	if err == nil && session.IsRevorked && bcrypt.CompareHashAndPassword([]byte(session.RefreshHash), refreshToken) == nil {
+		return ErrRefreshReuse
+	}
+	return fmt.Errorf("%w: refresh token matches no active session", ErrInvalidToken)
+}
+
+// VerifyConfirmation checks that a bound token is presented with the client certificate
+// (RFC 8705) and DPoP key (RFC 9449) it is bound to. Unbound tokens pass; cnf describes the
+// certificate and the verified DPoP proof of the current request and is nil without both.
+func VerifyConfirmation(claims *entity.Claimes, cnf *entity.Confirmation) error {
+	if claims.Confirmation == nil {
+		return nil
+	}
+	if cnf == nil {
+		cnf = &entity.Confirmation{}
+	}
+
+	if claims.Confirmation.X5tS256 != "" && subtle.ConstantTimeCompare([]byte(cnf.X5tS256), []byte(claims.Confirmation.X5tS256)) != 1 {
+		return ErrCertificateMismatch
+	}
+	if claims.Confirmation.JKT != "" && subtle.ConstantTimeCompare([]byte(cnf.JKT), []byte(claims.Confirmation.JKT)) != 1 {
+		return ErrDPoPKeyMismatch
+	}
+	return nil
+}
+
+func newTokenResponse(accessToken, refreshToken, scope string, cnf *entity.Confirmation) entity.TokenResponse {
+	return entity.TokenResponse{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    tokenType(cnf),
+		ExpiresIn:    int(AccessTokenTTL.Seconds()),
+		Scope:        scope,
+	}
+}
+
+// tokenType is DPoP for DPoP-bound tokens, which must be sent with the DPoP scheme.
+func tokenType(cnf *entity.Confirmation) string {
+	if cnf != nil && cnf.JKT != "" {
+		return TokenTypeDPoP
+	}
+	return TokenTypeBearer
 }
@@ -0,0 +1,208 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/BabyJhon/medods-test-task/internal/entity"
+	"github.com/BabyJhon/medods-test-task/internal/i18n"
+	"github.com/BabyJhon/medods-test-task/internal/repo/memory"
+	"github.com/BabyJhon/medods-test-task/internal/service"
+	"github.com/gofrs/uuid"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testSigningKey = "test-signing-key"
+
+func newSigningKeys(t *testing.T) []*service.SigningKey {
+	t.Helper()
+	key, err := service.GenerateSigningKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return []*service.SigningKey{key}
+}
+
+func testClaims(expiresAt time.Time) entity.Claimes {
+	return entity.Claimes{
+		SessionID: uuid.Must(uuid.NewV4()),
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   uuid.Must(uuid.NewV4()).String(),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+}
+
+func signToken(t *testing.T, method jwt.SigningMethod, key any, header map[string]any, expiresAt time.Time) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, testClaims(expiresAt))
+	for name, value := range header {
+		token.Header[name] = value
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestParseExpiredToken(t *testing.T) {
+	t.Setenv("SIGNING_KEY", testSigningKey)
+	keys := newSigningKeys(t)
+	foreignKeys := newSigningKeys(t)
+	auth := service.NewAuthService(nil, nil, nil, nil, keys, i18n.Translator{})
+
+	signAccessToken := func(expiresAt time.Time) string {
+		token, err := auth.SignAccessToken(testClaims(expiresAt))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	accessHeader := map[string]any{"kid": keys[0].ID, "typ": service.AccessTokenType}
+
+	tests := []struct {
+		name        string
+		token       string
+		valid       bool
+		validActive bool
+	}{
+		{
+			name:        "active token",
+			token:       signAccessToken(time.Now().Add(time.Minute)),
+			valid:       true,
+			validActive: true,
+		},
+		{
+			name:  "expired token",
+			token: signAccessToken(time.Now().Add(-time.Hour)),
+			valid: true,
+		},
+		{
+			name:  "expired token with foreign key",
+			token: signToken(t, jwt.SigningMethodRS256, foreignKeys[0].PrivateKey, map[string]any{"kid": foreignKeys[0].ID, "typ": service.AccessTokenType}, time.Now().Add(-time.Hour)),
+		},
+		{
+			name:  "ID token",
+			token: signToken(t, jwt.SigningMethodRS256, keys[0].PrivateKey, map[string]any{"kid": keys[0].ID}, time.Now().Add(time.Minute)),
+		},
+		{
+			name:  "unsigned token",
+			token: signToken(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, accessHeader, time.Now().Add(-time.Hour)),
+		},
+		{
+			name:        "legacy HS512 token",
+			token:       signToken(t, jwt.SigningMethodHS512, []byte(testSigningKey), nil, time.Now().Add(time.Minute)),
+			valid:       true,
+			validActive: true,
+		},
+		{
+			name:  "legacy HS512 token with foreign key",
+			token: signToken(t, jwt.SigningMethodHS512, []byte("other-key"), nil, time.Now().Add(-time.Hour)),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := auth.ParseExpiredToken(tt.token)
+			if tt.valid && (err != nil || claims == nil) {
+				t.Fatalf("ParseExpiredToken: %v", err)
+			}
+			if !tt.valid && err == nil {
+				t.Fatal("ParseExpiredToken accepted the token")
+			}
+
+			_, err = auth.Parsetoken(tt.token)
+			if tt.validActive && err != nil {
+				t.Fatalf("Parsetoken: %v", err)
+			}
+			if !tt.validActive && err == nil {
+				t.Fatal("Parsetoken accepted the token")
+			}
+		})
+	}
+}
+
+func TestParseTokenRejectsHS512WithoutSigningKey(t *testing.T) {
+	t.Setenv("SIGNING_KEY", "")
+	auth := service.NewAuthService(nil, nil, nil, nil, newSigningKeys(t), i18n.Translator{})
+
+	token := signToken(t, jwt.SigningMethodHS512, []byte(""), nil, time.Now().Add(time.Minute))
+	if _, err := auth.Parsetoken(token); err == nil {
+		t.Fatal("Parsetoken accepted an HS512 token signed with an empty key")
+	}
+}
+
+type failingWebhooks struct {
+	payloads []service.WebhookPayload
+}
+
+func (w *failingWebhooks) SendWebhook(ctx context.Context, payload service.WebhookPayload) error {
+	w.payloads = append(w.payloads, payload)
+	return errors.New("webhook is unavailable")
+}
+
+func (w *failingWebhooks) ListDeliveries(ctx context.Context, limit int) ([]entity.WebhookDelivery, error) {
+	return nil, nil
+}
+
+func (w *failingWebhooks) Drain(ctx context.Context) error {
+	return nil
+}
+
+func TestRefreshTokensWrongIP(t *testing.T) {
+	keys := newSigningKeys(t)
+	loginIP := net.ParseIP("10.0.0.1")
+
+	tests := []struct {
+		name     string
+		ip       net.IP
+		webhooks int
+		events   int
+	}{
+		{name: "same ip", ip: loginIP},
+		{name: "new ip", ip: net.ParseIP("10.0.0.2"), webhooks: 1, events: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repos := memory.NewRepository()
+			webhooks := &failingWebhooks{}
+			auth := service.NewAuthService(repos.Auth, repos.RBAC, repos.Events, webhooks, keys, i18n.Translator{})
+
+			userID := uuid.Must(uuid.NewV4())
+			tokens, err := auth.CreateTokens(ctx, userID, "test", loginIP, "", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			claims, err := auth.Parsetoken(tokens.AccessToken)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Отказ вебхука не должен мешать обновлению токенов.
+			if _, err := auth.RefreshTokens(ctx, *claims, tokens.RefreshToken, "test", tt.ip, nil); err != nil {
+				t.Fatalf("RefreshTokens: %v", err)
+			}
+			if len(webhooks.payloads) != tt.webhooks {
+				t.Fatalf("got %d webhooks, want %d", len(webhooks.payloads), tt.webhooks)
+			}
+
+			events, err := repos.Events.GetUserEvents(ctx, userID, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ipChanged := 0
+			for _, event := range events {
+				if event.Type == service.EventIPChanged {
+					ipChanged++
+				}
+			}
+			if ipChanged != tt.events {
+				t.Fatalf("got %d ip_changed events, want %d", ipChanged, tt.events)
+			}
+		})
+	}
+}
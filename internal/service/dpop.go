@@ -0,0 +1,51 @@
+package service
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/BabyJhon/medods-test-task/pkg/dpop"
+)
+
+var (
+	ErrInvalidDPoPProof = dpop.ErrInvalidProof
+	ErrDPoPKeyMismatch  = errors.New("token is bound to another dpop key")
+)
+
+type DPoPService struct {
+	verifier   *dpop.Verifier
+	requestURL func(r *http.Request) string
+}
+
+// NewDPoPService keeps used proofs in memory, so with several replicas a proof can be
+// replayed against another replica within its short lifetime. Proofs are checked against
+// the URLs under ISSUER_URL, the ones clients find in the discovery document, and against
+// the URL the service sees only when it isn't set.
+func NewDPoPService() *DPoPService {
+	requestURL := dpop.RequestURL
+	if iss := issuer(); iss != "" {
+		requestURL = dpop.ExternalURL(iss)
+	}
+
+	return &DPoPService{
+		verifier: &dpop.Verifier{
+			Replay: dpop.NewMemoryReplayCache(),
+		},
+		requestURL: requestURL,
+	}
+}
+
+// DPoPRequestURL returns the htu the client is expected to sign for the request.
+func (s *DPoPService) DPoPRequestURL(r *http.Request) string {
+	return s.requestURL(r)
+}
+
+// VerifyDPoPProof checks the proof for a request and returns the thumbprint of its key.
+// accessToken is empty when tokens are being issued and the presented token otherwise.
+func (s *DPoPService) VerifyDPoPProof(proof, method, htu, accessToken string) (string, error) {
+	verified, err := s.verifier.Verify(proof, method, htu, accessToken)
+	if err != nil {
+		return "", err
+	}
+	return verified.JKT, nil
+}
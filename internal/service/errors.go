@@ -0,0 +1,20 @@
+package service
+
+import (
+	"errors"
+
+	"github.com/BabyJhon/medods-test-task/internal/repo"
+)
+
+// Errors of the token flows. Handlers render them as problem types with stable codes, so
+// the details wrapped into them only reach the logs.
+var (
+	ErrInvalidToken     = errors.New("invalid token")
+	ErrTokenExpired     = errors.New("token is expired")
+	ErrSessionRevoked   = errors.New("session is revoked")
+	ErrUserAgentChanged = errors.New("user-agent has been changed")
+	ErrTokensMismatch   = errors.New("the tokens were not created together")
+	ErrRefreshReuse     = errors.New("refresh token has already been used")
+	// ErrNotFound is returned by the repositories for unknown sessions, clients and roles.
+	ErrNotFound = repo.ErrNotFound
+)
@@ -0,0 +1,48 @@
+package service
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/BabyJhon/medods-test-task/internal/entity"
+	"github.com/BabyJhon/medods-test-task/internal/logging"
+	"github.com/BabyJhon/medods-test-task/internal/repo"
+	"github.com/gofrs/uuid"
+)
+
+const (
+	EventLogin            = "login"
+	EventRefresh          = "refresh"
+	EventUserAgentChanged = "user_agent_changed"
+	EventIPChanged        = "ip_changed"
+	EventSessionRevoked   = "session_revoked"
+	EventLogout           = "logout"
+)
+
+// recordEvent stores a security event of the session's user. An event that can't be stored
+// must not fail the operation that caused it, so errors are only logged.
+func recordEvent(ctx context.Context, events repo.Events, eventType string, session entity.Session, ip net.IP, userAgent, details string) {
+	id, err := uuid.DefaultGenerator.NewV4()
+	if err != nil {
+		logging.FromContext(ctx).Errorf("failed to record security event: %s", err.Error())
+		return
+	}
+
+	event := entity.SecurityEvent{
+		ID:        id,
+		UserID:    session.UserId,
+		Type:      eventType,
+		IP:        ip,
+		UserAgent: userAgent,
+		Details:   details,
+		CreatedAt: time.Now(),
+	}
+	if session.ID != uuid.Nil {
+		event.SessionID = uuid.NullUUID{UUID: session.ID, Valid: true}
+	}
+
+	if err := events.CreateEvent(ctx, event); err != nil {
+		logging.FromContext(ctx).Errorf("failed to record security event: %s", err.Error())
+	}
+}
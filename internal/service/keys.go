@@ -0,0 +1,191 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/BabyJhon/medods-test-task/pkg/jwk"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	signingKeyBits = 2048
+)
+
+type SigningKey struct {
+	ID         string
+	PrivateKey *rsa.PrivateKey
+}
+
+// LoadSigningKeys reads RSA private keys in PEM (PKCS#1 or PKCS#8) from path. The first key
+// signs new access and ID tokens, the rest are previous keys that stay in JWKS after a
+// rotation, so tokens signed with them can still be verified.
+// With an empty path an ephemeral key is generated, so tokens issued before a restart
+// can't be verified afterwards and their sessions can't be refreshed.
+func LoadSigningKeys(path string) ([]*SigningKey, error) {
+	if path == "" {
+		logrus.Warn("OIDC_SIGNING_KEY_FILE is not set, using ephemeral signing key")
+
+		key, err := GenerateSigningKey()
+		if err != nil {
+			return nil, err
+		}
+		return []*SigningKey{key}, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	privateKeys, err := ParseRSAPrivateKeys(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	keys := make([]*SigningKey, 0, len(privateKeys))
+	for _, privateKey := range privateKeys {
+		key, err := NewSigningKey(privateKey)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+func GenerateSigningKey() (*SigningKey, error) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, signingKeyBits)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewSigningKey(privateKey)
+}
+
+// RotateSigningKeys puts a new key in front of keys and keeps at most keep previous keys.
+func RotateSigningKeys(keys []*SigningKey, keep int) ([]*SigningKey, error) {
+	key, err := GenerateSigningKey()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(keys) > keep {
+		keys = keys[:keep]
+	}
+
+	return append([]*SigningKey{key}, keys...), nil
+}
+
+// EncodeSigningKeys encodes keys as PKCS#8 PEM blocks in the format read by LoadSigningKeys.
+func EncodeSigningKeys(keys []*SigningKey) ([]byte, error) {
+	var data []byte
+	for _, key := range keys {
+		der, err := x509.MarshalPKCS8PrivateKey(key.PrivateKey)
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})...)
+	}
+
+	return data, nil
+}
+
+func NewSigningKey(privateKey *rsa.PrivateKey) (*SigningKey, error) {
+	publicJWK, err := jwk.FromPublicKey(&privateKey.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	kid, err := publicJWK.Thumbprint()
+	if err != nil {
+		return nil, err
+	}
+
+	return &SigningKey{
+		ID:         kid,
+		PrivateKey: privateKey,
+	}, nil
+}
+
+func ParseRSAPrivateKeys(data []byte) ([]*rsa.PrivateKey, error) {
+	var keys []*rsa.PrivateKey
+	for {
+		block, rest := pem.Decode(data)
+		if block == nil {
+			break
+		}
+		data = rest
+
+		key, err := parseRSAPrivateKey(block)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	if len(keys) == 0 {
+		return nil, errors.New("no PEM data found")
+	}
+
+	return keys, nil
+}
+
+func parseRSAPrivateKey(block *pem.Block) (*rsa.PrivateKey, error) {
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("signing key is not an RSA key")
+	}
+
+	return rsaKey, nil
+}
+
+// signJWT signs claims with keys[0] and names it in the kid header, so the token can be
+// verified with the keys published in JWKS.
+func signJWT(keys []*SigningKey, claims jwt.Claims, typ string) (string, error) {
+	if len(keys) == 0 {
+		return "", errors.New("no signing key")
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = keys[0].ID
+	if typ != "" {
+		token.Header["typ"] = typ
+	}
+	return token.SignedString(keys[0].PrivateKey)
+}
+
+// publicKey returns the public key named by the kid header of the token.
+func publicKey(keys []*SigningKey, token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	for _, key := range keys {
+		if key.ID == kid {
+			return &key.PrivateKey.PublicKey, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+func (k *SigningKey) JWK() jwk.Key {
+	publicJWK, _ := jwk.FromPublicKey(&k.PrivateKey.PublicKey)
+	publicJWK.Kid = k.ID
+	publicJWK.Use = "sig"
+	publicJWK.Alg = "RS256"
+	return publicJWK
+}
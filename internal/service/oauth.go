@@ -0,0 +1,448 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/BabyJhon/medods-test-task/internal/entity"
+	"github.com/BabyJhon/medods-test-task/internal/repo"
+	"github.com/gofrs/uuid"
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	authorizationCodeTTL = 5 * time.Minute
+	codeChallengeS256    = "S256"
+	minCodeVerifierLen   = 43
+	maxCodeVerifierLen   = 128
+
+	GrantTypeAuthorizationCode = "authorization_code"
+	GrantTypeRefreshToken      = "refresh_token"
+	GrantTypeClientCredentials = "client_credentials"
+
+	TokenTypeBearer = "Bearer"
+	TokenTypeDPoP   = "DPoP"
+)
+
+var supportedGrantTypes = []string{GrantTypeAuthorizationCode, GrantTypeRefreshToken, GrantTypeClientCredentials}
+
+var (
+	ErrInvalidClient           = errors.New("invalid client")
+	ErrInvalidRedirectURI      = errors.New("redirect_uri is not registered for the client")
+	ErrInvalidRequest          = errors.New("invalid request")
+	ErrUnsupportedResponseType = errors.New("unsupported response_type")
+	ErrInvalidGrant            = errors.New("invalid grant")
+	ErrUnauthorizedClient      = errors.New("client is not allowed to use this grant type")
+	ErrInvalidScope            = errors.New("invalid scope")
+)
+
+type OAuthService struct {
+	repo repo.OAuth
+	auth Auth
+	oidc OIDC
+}
+
+func NewOAuthService(repo repo.OAuth, auth Auth, oidc OIDC) *OAuthService {
+	return &OAuthService{
+		repo: repo,
+		auth: auth,
+		oidc: oidc,
+	}
+}
+
+func (s *OAuthService) getActiveClient(ctx context.Context, clientID string) (entity.OAuthClient, error) {
+	if clientID == "" {
+		return entity.OAuthClient{}, fmt.Errorf("%w: client_id is required", ErrInvalidClient)
+	}
+
+	client, err := s.repo.GetClientByID(ctx, clientID)
+	if errors.Is(err, ErrNotFound) {
+		return entity.OAuthClient{}, fmt.Errorf("%w: %w", ErrInvalidClient, err)
+	}
+	if err != nil {
+		return entity.OAuthClient{}, err
+	}
+	if client.IsDisabled {
+		return entity.OAuthClient{}, fmt.Errorf("%w: client is disabled", ErrInvalidClient)
+	}
+
+	return client, nil
+}
+
+// authenticateClient checks the client secret. Public clients have no secret and are
+// authenticated by PKCE instead, so only the client_id is checked for them.
+func (s *OAuthService) authenticateClient(ctx context.Context, clientID, clientSecret string) (entity.OAuthClient, error) {
+	client, err := s.getActiveClient(ctx, clientID)
+	if err != nil {
+		return entity.OAuthClient{}, err
+	}
+
+	if client.IsPublic {
+		return client, nil
+	}
+
+	if clientSecret == "" || bcrypt.CompareHashAndPassword([]byte(client.SecretHash), []byte(clientSecret)) != nil {
+		return entity.OAuthClient{}, fmt.Errorf("%w: client authentication failed", ErrInvalidClient)
+	}
+
+	return client, nil
+}
+
+func (s *OAuthService) ValidateClient(ctx context.Context, clientID, redirectURI string) error {
+	client, err := s.getActiveClient(ctx, clientID)
+	if err != nil {
+		return err
+	}
+
+	if !slices.Contains(client.RedirectURIs, redirectURI) {
+		return ErrInvalidRedirectURI
+	}
+
+	return nil
+}
+
+func (s *OAuthService) Authorize(ctx context.Context, req entity.AuthorizeRequest) (string, error) {
+	client, err := s.getActiveClient(ctx, req.ClientID)
+	if err != nil {
+		return "", err
+	}
+	if !slices.Contains(client.RedirectURIs, req.RedirectURI) {
+		return "", ErrInvalidRedirectURI
+	}
+	if !slices.Contains(client.GrantTypes, GrantTypeAuthorizationCode) {
+		return "", ErrUnauthorizedClient
+	}
+
+	if req.ResponseType != "code" {
+		return "", ErrUnsupportedResponseType
+	}
+	if req.State == "" {
+		return "", fmt.Errorf("%w: state is required", ErrInvalidRequest)
+	}
+	if req.CodeChallenge == "" {
+		return "", fmt.Errorf("%w: code_challenge is required", ErrInvalidRequest)
+	}
+	if req.CodeChallengeMethod != codeChallengeS256 {
+		return "", fmt.Errorf("%w: only S256 code_challenge_method is supported", ErrInvalidRequest)
+	}
+	if err := checkScopes(client, strings.Fields(req.Scope)); err != nil {
+		return "", err
+	}
+
+	code, err := generateAuthorizationCode()
+	if err != nil {
+		return "", err
+	}
+
+	err = s.repo.CreateAuthorizationCode(ctx, entity.AuthorizationCode{
+		CodeHash:            hashAuthorizationCode(code),
+		ClientID:            req.ClientID,
+		UserID:              req.UserID,
+		RedirectURI:         req.RedirectURI,
+		CodeChallenge:       req.CodeChallenge,
+		CodeChallengeMethod: req.CodeChallengeMethod,
+		Scope:               req.Scope,
+		Nonce:               req.Nonce,
+		AuthTime:            time.Now(),
+		CreatedAt:           time.Now(),
+		ExpiresAt:           time.Now().Add(authorizationCodeTTL),
+		IsUsed:              false,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return code, nil
+}
+
+func (s *OAuthService) ExchangeCode(ctx context.Context, req entity.TokenRequest, userAgent string, clientIP net.IP) (entity.TokenResponse, error) {
+	client, err := s.authenticateClient(ctx, req.ClientID, req.ClientSecret)
+	if err != nil {
+		return entity.TokenResponse{}, err
+	}
+	if !slices.Contains(client.GrantTypes, GrantTypeAuthorizationCode) {
+		return entity.TokenResponse{}, ErrUnauthorizedClient
+	}
+
+	if req.Code == "" || req.CodeVerifier == "" {
+		return entity.TokenResponse{}, fmt.Errorf("%w: code and code_verifier are required", ErrInvalidRequest)
+	}
+	if len(req.CodeVerifier) < minCodeVerifierLen || len(req.CodeVerifier) > maxCodeVerifierLen {
+		return entity.TokenResponse{}, fmt.Errorf("%w: code_verifier must be between %d and %d characters", ErrInvalidRequest, minCodeVerifierLen, maxCodeVerifierLen)
+	}
+
+	code, err := s.repo.UseAuthorizationCode(ctx, hashAuthorizationCode(req.Code))
+	if errors.Is(err, ErrNotFound) {
+		return entity.TokenResponse{}, fmt.Errorf("%w: %w", ErrInvalidGrant, err)
+	}
+	if err != nil {
+		return entity.TokenResponse{}, err
+	}
+
+	if code.ExpiresAt.Before(time.Now()) {
+		return entity.TokenResponse{}, fmt.Errorf("%w: authorization code is expired", ErrInvalidGrant)
+	}
+	if code.ClientID != client.ClientID {
+		return entity.TokenResponse{}, fmt.Errorf("%w: authorization code was issued to another client", ErrInvalidGrant)
+	}
+	if code.RedirectURI != req.RedirectURI {
+		return entity.TokenResponse{}, fmt.Errorf("%w: redirect_uri does not match", ErrInvalidGrant)
+	}
+
+	if err := checkDPoPRequired(client, req.Confirmation); err != nil {
+		return entity.TokenResponse{}, err
+	}
+
+	challenge := sha256.Sum256([]byte(req.CodeVerifier))
+	expected := base64.RawURLEncoding.EncodeToString(challenge[:])
+	if subtle.ConstantTimeCompare([]byte(expected), []byte(code.CodeChallenge)) != 1 {
+		return entity.TokenResponse{}, fmt.Errorf("%w: code_verifier does not match code_challenge", ErrInvalidGrant)
+	}
+
+	response, err := s.auth.CreateTokens(ctx, code.UserID, userAgent, clientIP, code.Scope, req.Confirmation)
+	if err != nil {
+		return entity.TokenResponse{}, err
+	}
+
+	if slices.Contains(strings.Fields(code.Scope), ScopeOpenID) {
+		response.IDToken, err = s.oidc.GenerateIDToken(code.UserID, code.ClientID, code.Nonce, code.AuthTime)
+		if err != nil {
+			return entity.TokenResponse{}, err
+		}
+	}
+
+	return response, nil
+}
+
+// ClientCredentials issues an access token to the client itself. There is no user and no
+// refresh session behind such a token, so it can't be refreshed or revoked through /revoke.
+func (s *OAuthService) ClientCredentials(ctx context.Context, req entity.TokenRequest) (entity.TokenResponse, error) {
+	client, err := s.authenticateClient(ctx, req.ClientID, req.ClientSecret)
+	if err != nil {
+		return entity.TokenResponse{}, err
+	}
+	if client.IsPublic || !slices.Contains(client.GrantTypes, GrantTypeClientCredentials) {
+		return entity.TokenResponse{}, ErrUnauthorizedClient
+	}
+	if err := checkDPoPRequired(client, req.Confirmation); err != nil {
+		return entity.TokenResponse{}, err
+	}
+
+	scopes := client.Scopes
+	if req.Scope != "" {
+		scopes = strings.Fields(req.Scope)
+		if err := checkScopes(client, scopes); err != nil {
+			return entity.TokenResponse{}, err
+		}
+	}
+	scope := strings.Join(scopes, " ")
+
+	signedToken, err := s.auth.SignAccessToken(entity.Claimes{
+		ClientID:     client.ClientID,
+		Scope:        scope,
+		Confirmation: req.Confirmation,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   client.ClientID,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(AccessTokenTTL)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	})
+	if err != nil {
+		return entity.TokenResponse{}, err
+	}
+
+	return newTokenResponse(signedToken, "", scope, req.Confirmation), nil
+}
+
+// Introspect reports whether the access token is active (RFC 7662). Tokens of revoked or
+// deleted sessions are reported as inactive. Only confidential clients may introspect.
+func (s *OAuthService) Introspect(ctx context.Context, req entity.TokenRequest, token string) (entity.IntrospectionResponse, error) {
+	client, err := s.authenticateClient(ctx, req.ClientID, req.ClientSecret)
+	if err != nil {
+		return entity.IntrospectionResponse{}, err
+	}
+	if client.IsPublic {
+		return entity.IntrospectionResponse{}, ErrUnauthorizedClient
+	}
+	if token == "" {
+		return entity.IntrospectionResponse{}, fmt.Errorf("%w: token is required", ErrInvalidRequest)
+	}
+
+	claims, err := s.auth.Parsetoken(token)
+	if err != nil {
+		return entity.IntrospectionResponse{Active: false}, nil
+	}
+
+	if claims.SessionID != uuid.Nil {
+		if _, err := s.auth.GetSession(ctx, *claims); err != nil {
+			return entity.IntrospectionResponse{Active: false}, nil
+		}
+	}
+
+	response := entity.IntrospectionResponse{
+		Active:    true,
+		Scope:     claims.Scope,
+		ClientID:  claims.ClientID,
+		Sub:       claims.Subject,
+		TokenType: tokenType(claims.Confirmation),
+		Roles:     claims.Roles,
+		// The resource server compares cnf with the certificate of its own connection
+		// and the key of the DPoP proof it received.
+		Confirmation: claims.Confirmation,
+	}
+	if claims.SessionID != uuid.Nil {
+		response.SessionID = claims.SessionID.String()
+	}
+	if claims.ExpiresAt != nil {
+		response.Exp = claims.ExpiresAt.Unix()
+	}
+	if claims.IssuedAt != nil {
+		response.Iat = claims.IssuedAt.Unix()
+	}
+
+	return response, nil
+}
+
+func (s *OAuthService) CreateClient(ctx context.Context, input entity.OAuthClient) (entity.OAuthClient, string, error) {
+	if input.Name == "" {
+		return entity.OAuthClient{}, "", fmt.Errorf("%w: name is required", ErrInvalidRequest)
+	}
+	if len(input.GrantTypes) == 0 {
+		return entity.OAuthClient{}, "", fmt.Errorf("%w: at least one grant type is required", ErrInvalidRequest)
+	}
+	for _, grantType := range input.GrantTypes {
+		if !slices.Contains(supportedGrantTypes, grantType) {
+			return entity.OAuthClient{}, "", fmt.Errorf("%w: unsupported grant type %q", ErrInvalidRequest, grantType)
+		}
+	}
+	if slices.Contains(input.GrantTypes, GrantTypeAuthorizationCode) && len(input.RedirectURIs) == 0 {
+		return entity.OAuthClient{}, "", fmt.Errorf("%w: authorization_code grant requires redirect_uris", ErrInvalidRequest)
+	}
+	if input.IsPublic && slices.Contains(input.GrantTypes, GrantTypeClientCredentials) {
+		return entity.OAuthClient{}, "", fmt.Errorf("%w: public clients can't use client_credentials grant", ErrInvalidRequest)
+	}
+
+	clientID, err := uuid.DefaultGenerator.NewV4()
+	if err != nil {
+		return entity.OAuthClient{}, "", err
+	}
+
+	client := entity.OAuthClient{
+		ClientID:              clientID.String(),
+		Name:                  input.Name,
+		GrantTypes:            input.GrantTypes,
+		Scopes:                nonNil(input.Scopes),
+		RedirectURIs:          nonNil(input.RedirectURIs),
+		IsPublic:              input.IsPublic,
+		IsDisabled:            false,
+		DPoPBoundAccessTokens: input.DPoPBoundAccessTokens,
+		CreatedAt:             time.Now(),
+		UpdatedAt:             time.Now(),
+	}
+
+	var secret string
+	if !client.IsPublic {
+		secret, client.SecretHash, err = generateClientSecret()
+		if err != nil {
+			return entity.OAuthClient{}, "", err
+		}
+	}
+
+	if err := s.repo.CreateClient(ctx, client); err != nil {
+		return entity.OAuthClient{}, "", err
+	}
+
+	return client, secret, nil
+}
+
+func (s *OAuthService) RotateClientSecret(ctx context.Context, clientID string) (string, error) {
+	client, err := s.repo.GetClientByID(ctx, clientID)
+	if err != nil {
+		return "", err
+	}
+	if client.IsPublic {
+		return "", fmt.Errorf("%w: public clients have no secret", ErrInvalidRequest)
+	}
+
+	secret, secretHash, err := generateClientSecret()
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.repo.UpdateClientSecret(ctx, clientID, secretHash); err != nil {
+		return "", err
+	}
+
+	return secret, nil
+}
+
+func (s *OAuthService) DisableClient(ctx context.Context, clientID string) error {
+	return s.repo.SetClientDisabled(ctx, clientID, true)
+}
+
+// checkDPoPRequired rejects token requests without a DPoP proof from clients registered
+// with dpop_bound_access_tokens. Other clients may still send a proof to get bound tokens.
+func checkDPoPRequired(client entity.OAuthClient, cnf *entity.Confirmation) error {
+	if client.DPoPBoundAccessTokens && (cnf == nil || cnf.JKT == "") {
+		return fmt.Errorf("%w: the client requires a dpop proof", ErrInvalidDPoPProof)
+	}
+	return nil
+}
+
+func checkScopes(client entity.OAuthClient, scopes []string) error {
+	for _, scope := range scopes {
+		if !slices.Contains(client.Scopes, scope) {
+			return fmt.Errorf("%w: scope %q is not allowed for the client", ErrInvalidScope, scope)
+		}
+	}
+	return nil
+}
+
+func generateClientSecret() (string, string, error) {
+	secretBytes := make([]byte, 32)
+
+	if _, err := rand.Read(secretBytes); err != nil {
+		return "", "", fmt.Errorf("client secret generation error: %w", err)
+	}
+	secret := base64.RawURLEncoding.EncodeToString(secretBytes)
+
+	secretHash, err := bcrypt.GenerateFromPassword([]byte(secret), bcrypt.DefaultCost)
+	if err != nil {
+		return "", "", err
+	}
+
+	return secret, string(secretHash), nil
+}
+
+func generateAuthorizationCode() (string, error) {
+	codeBytes := make([]byte, 32)
+
+	if _, err := rand.Read(codeBytes); err != nil {
+		return "", fmt.Errorf("authorization code generation error: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(codeBytes), nil
+}
+
+func hashAuthorizationCode(code string) string {
+	hash := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(hash[:])
+}
+
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/BabyJhon/medods-test-task/internal/entity"
+	"github.com/BabyJhon/medods-test-task/internal/repo"
+	"github.com/BabyJhon/medods-test-task/pkg/dpop"
+	"github.com/BabyJhon/medods-test-task/pkg/jwk"
+	"github.com/gofrs/uuid"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	ScopeOpenID = "openid"
+
+	// apiV1Path is the root the endpoints of the discovery document are served under.
+	apiV1Path = "/api/v1"
+
+	idTokenTTL = 15 * time.Minute
+	// Пользователь идентифицируется только по guid, поэтому стандартные значения amr (RFC 8176) не подходят.
+	amrGUID = "guid"
+)
+
+type OIDCService struct {
+	repo repo.Auth
+	// keys[0] signs ID tokens, all keys are published in JWKS.
+	keys []*SigningKey
+}
+
+func NewOIDCService(repo repo.Auth, keys []*SigningKey) *OIDCService {
+	return &OIDCService{
+		repo: repo,
+		keys: keys,
+	}
+}
+
+func issuer() string {
+	return strings.TrimSuffix(os.Getenv("ISSUER_URL"), "/")
+}
+
+func (s *OIDCService) Discovery() entity.OpenIDConfiguration {
+	iss := issuer()
+
+	return entity.OpenIDConfiguration{
+		Issuer:                            iss,
+		AuthorizationEndpoint:             iss + apiV1Path + "/oauth/authorize",
+		TokenEndpoint:                     iss + apiV1Path + "/oauth/token",
+		UserInfoEndpoint:                  iss + apiV1Path + "/userinfo",
+		IntrospectionEndpoint:             iss + apiV1Path + "/oauth/introspect",
+		JWKSURI:                           iss + "/.well-known/jwks.json",
+		ScopesSupported:                   []string{ScopeOpenID},
+		ResponseTypesSupported:            []string{"code"},
+		GrantTypesSupported:               supportedGrantTypes,
+		SubjectTypesSupported:             []string{"public"},
+		IDTokenSigningAlgValuesSupported:  []string{jwt.SigningMethodRS256.Alg()},
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post", "none"},
+		CodeChallengeMethodsSupported:     []string{codeChallengeS256},
+		ClaimsSupported:                   []string{"iss", "sub", "aud", "exp", "iat", "nonce", "auth_time", "amr"},
+		CertificateBoundAccessTokens:      true,
+		DPoPSigningAlgValuesSupported:     dpop.SigningAlgorithms(),
+	}
+}
+
+func (s *OIDCService) JWKS() jwk.Set {
+	set := jwk.Set{Keys: make([]jwk.Key, 0, len(s.keys))}
+	for _, key := range s.keys {
+		set.Keys = append(set.Keys, key.JWK())
+	}
+	return set
+}
+
+func (s *OIDCService) GenerateIDToken(userID uuid.UUID, clientID, nonce string, authTime time.Time) (string, error) {
+	return signJWT(s.keys, entity.IDTokenClaims{
+		Nonce:    nonce,
+		AuthTime: jwt.NewNumericDate(authTime),
+		AMR:      []string{amrGUID},
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    issuer(),
+			Subject:   userID.String(),
+			Audience:  jwt.ClaimStrings{clientID},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(idTokenTTL)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}, "")
+}
+
+// ParseIDToken verifies an ID token issued by this service with the key from its kid header.
+func (s *OIDCService) ParseIDToken(idToken string) (*entity.IDTokenClaims, error) {
+	token, err := jwt.ParseWithClaims(idToken, &entity.IDTokenClaims{}, func(token *jwt.Token) (interface{}, error) {
+		// Access токены подписываются тем же ключом и отличаются только typ.
+		if typ, _ := token.Header["typ"].(string); strings.EqualFold(typ, AccessTokenType) {
+			return nil, errors.New("access token is not an ID token")
+		}
+		return publicKey(s.keys, token)
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg()}), jwt.WithIssuer(issuer()))
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(*entity.IDTokenClaims)
+	if !ok {
+		return nil, errors.New("token claims are not of type *IDTokenClaims")
+	}
+
+	return claims, nil
+}
+
+func (s *OIDCService) UserInfo(ctx context.Context, token entity.Claimes) (entity.UserInfo, error) {
+	session, err := s.repo.GetSessionByID(ctx, token.SessionID)
+	if errors.Is(err, ErrNotFound) {
+		return entity.UserInfo{}, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+	if err != nil {
+		return entity.UserInfo{}, err
+	}
+	if session.IsRevorked {
+		return entity.UserInfo{}, ErrSessionRevoked
+	}
+
+	return entity.UserInfo{Sub: session.UserId.String()}, nil
+}
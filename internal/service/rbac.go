@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/BabyJhon/medods-test-task/internal/entity"
+	"github.com/BabyJhon/medods-test-task/internal/repo"
+	"github.com/gofrs/uuid"
+)
+
+const (
+	RoleAdmin  = "admin"
+	ScopeAdmin = "admin"
+)
+
+type RBACService struct {
+	repo repo.RBAC
+}
+
+func NewRBACService(repo repo.RBAC) *RBACService {
+	return &RBACService{
+		repo: repo,
+	}
+}
+
+func (s *RBACService) GetUserRoles(ctx context.Context, userID uuid.UUID) ([]entity.Role, error) {
+	return s.repo.GetUserRoles(ctx, userID)
+}
+
+func (s *RBACService) ListRoles(ctx context.Context) ([]entity.Role, error) {
+	return s.repo.ListRoles(ctx)
+}
+
+func (s *RBACService) UpsertRole(ctx context.Context, role entity.Role) error {
+	if role.Name == "" {
+		return fmt.Errorf("%w: role name is required", ErrInvalidRequest)
+	}
+	for _, permission := range role.Permissions {
+		if permission == "" || strings.ContainsAny(permission, " \t\n") {
+			return fmt.Errorf("%w: invalid permission %q", ErrInvalidRequest, permission)
+		}
+	}
+
+	role.Permissions = nonNil(role.Permissions)
+	role.CreatedAt = time.Now()
+
+	return s.repo.UpsertRole(ctx, role)
+}
+
+func (s *RBACService) AssignRole(ctx context.Context, userID uuid.UUID, role string) error {
+	return s.repo.AssignRole(ctx, userID, role)
+}
+
+func (s *RBACService) RemoveRole(ctx context.Context, userID uuid.UUID, role string) error {
+	return s.repo.RemoveRole(ctx, userID, role)
+}
+
+// grantScopes narrows the requested scopes down to the permissions of the user's roles.
+// openid is not a permission and is always granted, admin is granted only with allowAdmin.
+func grantScopes(ctx context.Context, rbac repo.RBAC, userID uuid.UUID, requested string, allowAdmin bool) (string, []string, error) {
+	roles, err := rbac.GetUserRoles(ctx, userID)
+	if err != nil {
+		return "", nil, err
+	}
+
+	roleNames := make([]string, 0, len(roles))
+	var permissions []string
+	for _, role := range roles {
+		roleNames = append(roleNames, role.Name)
+		permissions = append(permissions, role.Permissions...)
+	}
+
+	var granted []string
+	for _, scope := range strings.Fields(requested) {
+		if scope == ScopeAdmin && !allowAdmin {
+			continue
+		}
+		if (scope == ScopeOpenID || slices.Contains(permissions, scope)) && !slices.Contains(granted, scope) {
+			granted = append(granted, scope)
+		}
+	}
+
+	return strings.Join(granted, " "), roleNames, nil
+}
@@ -0,0 +1,57 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/BabyJhon/medods-test-task/internal/i18n"
+	"github.com/BabyJhon/medods-test-task/internal/repo/memory"
+	"github.com/BabyJhon/medods-test-task/internal/service"
+	"github.com/gofrs/uuid"
+)
+
+func TestAdminScopeIsNotGrantedByGUID(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.NewRepository()
+	auth := service.NewAuthService(repos.Auth, repos.RBAC, repos.Events, nil, newSigningKeys(t), i18n.Translator{})
+
+	adminID := uuid.Must(uuid.NewV4())
+	if err := repos.RBAC.AssignRole(ctx, adminID, service.RoleAdmin); err != nil {
+		t.Fatal(err)
+	}
+	ip := net.ParseIP("10.0.0.1")
+
+	tokens, err := auth.CreateTokens(ctx, adminID, "test", ip, "openid admin", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tokens.Scope != service.ScopeOpenID {
+		t.Errorf("CreateTokens scope = %q, want %q", tokens.Scope, service.ScopeOpenID)
+	}
+
+	tokens, err = auth.CreateAdminTokens(ctx, adminID, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := auth.Parsetoken(tokens.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.Scope != service.ScopeAdmin {
+		t.Errorf("CreateAdminTokens scope = %q, want %q", claims.Scope, service.ScopeAdmin)
+	}
+
+	refreshed, err := auth.RefreshTokens(ctx, *claims, tokens.RefreshToken, "test", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if refreshed.Scope != "" {
+		t.Errorf("refreshed scope = %q, want none", refreshed.Scope)
+	}
+
+	if _, err := auth.CreateAdminTokens(ctx, uuid.Must(uuid.NewV4()), "test"); !errors.Is(err, service.ErrInvalidScope) {
+		t.Errorf("CreateAdminTokens without the admin role: got %v, want %v", err, service.ErrInvalidScope)
+	}
+}
@@ -3,28 +3,98 @@ package service
 import (
 	"context"
 	"net"
+	"net/http"
+	"time"
 
 	"github.com/BabyJhon/medods-test-task/internal/entity"
+	"github.com/BabyJhon/medods-test-task/internal/i18n"
 	"github.com/BabyJhon/medods-test-task/internal/repo"
+	"github.com/BabyJhon/medods-test-task/pkg/jwk"
 	"github.com/gofrs/uuid"
 )
 
 type Auth interface {
-	generateAccessToken(userID, sessionID uuid.UUID, userAgent string, clientIP net.IP) (string, error)
+	generateAccessToken(userID, sessionID uuid.UUID, userAgent string, clientIP net.IP, scope string, roles []string, cnf *entity.Confirmation) (string, error)
 	generateRefreshToken() ([]byte, error)
-	CreateTokens(ctx context.Context, guid uuid.UUID, userAgent string, clientIP net.IP) (string, string, error)
+	CreateTokens(ctx context.Context, guid uuid.UUID, userAgent string, clientIP net.IP, scope string, cnf *entity.Confirmation) (entity.TokenResponse, error)
 	Parsetoken(accessToken string) (*entity.Claimes, error)
+	ParseExpiredToken(accessToken string) (*entity.Claimes, error)
+	SignAccessToken(claims entity.Claimes) (string, error)
 	GetSession(ctx context.Context, token entity.Claimes) (entity.Session, error)
 	RevokeToken(ctx context.Context, sessionID uuid.UUID) error
-	RefreshTokens(ctx context.Context, accessToken entity.Claimes, base64RefreshToken string, userAgent string, IP net.IP) (string, string, error)
+	RefreshTokens(ctx context.Context, accessToken entity.Claimes, base64RefreshToken string, userAgent string, IP net.IP, cnf *entity.Confirmation) (entity.TokenResponse, error)
+}
+
+type OAuth interface {
+	ValidateClient(ctx context.Context, clientID, redirectURI string) error
+	Authorize(ctx context.Context, req entity.AuthorizeRequest) (string, error)
+	ExchangeCode(ctx context.Context, req entity.TokenRequest, userAgent string, clientIP net.IP) (entity.TokenResponse, error)
+	ClientCredentials(ctx context.Context, req entity.TokenRequest) (entity.TokenResponse, error)
+	Introspect(ctx context.Context, req entity.TokenRequest, token string) (entity.IntrospectionResponse, error)
+	CreateClient(ctx context.Context, input entity.OAuthClient) (entity.OAuthClient, string, error)
+	RotateClientSecret(ctx context.Context, clientID string) (string, error)
+	DisableClient(ctx context.Context, clientID string) error
+}
+
+type OIDC interface {
+	Discovery() entity.OpenIDConfiguration
+	JWKS() jwk.Set
+	GenerateIDToken(userID uuid.UUID, clientID, nonce string, authTime time.Time) (string, error)
+	ParseIDToken(idToken string) (*entity.IDTokenClaims, error)
+	UserInfo(ctx context.Context, token entity.Claimes) (entity.UserInfo, error)
+}
+
+type RBAC interface {
+	GetUserRoles(ctx context.Context, userID uuid.UUID) ([]entity.Role, error)
+	ListRoles(ctx context.Context) ([]entity.Role, error)
+	UpsertRole(ctx context.Context, role entity.Role) error
+	AssignRole(ctx context.Context, userID uuid.UUID, role string) error
+	RemoveRole(ctx context.Context, userID uuid.UUID, role string) error
+}
+
+type Sessions interface {
+	ListUserSessions(ctx context.Context, userID uuid.UUID) ([]entity.Session, error)
+	SearchSessions(ctx context.Context, filter entity.SessionFilter) ([]entity.Session, error)
+	RevokeSessions(ctx context.Context, ids []uuid.UUID) (int64, error)
+	LogoutUser(ctx context.Context, userID uuid.UUID) (int64, error)
+	PurgeExpiredSessions(ctx context.Context) (int64, error)
+	GetUserEvents(ctx context.Context, userID uuid.UUID, limit int) ([]entity.SecurityEvent, error)
+	ListUsers(ctx context.Context, limit int) ([]entity.UserSummary, error)
+}
+
+type Webhooks interface {
+	SendWebhook(ctx context.Context, payload WebhookPayload) error
+	ListDeliveries(ctx context.Context, limit int) ([]entity.WebhookDelivery, error)
+	Drain(ctx context.Context) error
+}
+
+type DPoP interface {
+	DPoPRequestURL(r *http.Request) string
+	VerifyDPoPProof(proof, method, htu, accessToken string) (string, error)
 }
 
 type Service struct {
 	Auth
+	OAuth
+	OIDC
+	RBAC
+	Sessions
+	Webhooks
+	DPoP
 }
 
-func NewService(repos *repo.Repository) *Service {
+func NewService(repos *repo.Repository, signingKeys []*SigningKey, webhookMessages i18n.Translator) *Service {
+	webhooks := NewWebhookService(repos.Webhooks)
+	auth := NewAuthService(repos.Auth, repos.RBAC, repos.Events, webhooks, signingKeys, webhookMessages)
+	oidc := NewOIDCService(repos.Auth, signingKeys)
+
 	return &Service{
-		Auth: NewAuthService(repos),
+		Auth:     auth,
+		OAuth:    NewOAuthService(repos.OAuth, auth, oidc),
+		OIDC:     oidc,
+		RBAC:     NewRBACService(repos.RBAC),
+		Sessions: NewSessionService(repos.Auth, repos.Events),
+		Webhooks: webhooks,
+		DPoP:     NewDPoPService(),
 	}
 }
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/BabyJhon/medods-test-task/internal/entity"
+	"github.com/BabyJhon/medods-test-task/internal/repo"
+	"github.com/gofrs/uuid"
+)
+
+const (
+	defaultListLimit = 100
+	maxListLimit     = 1000
+)
+
+type SessionService struct {
+	repo   repo.Auth
+	events repo.Events
+}
+
+func NewSessionService(repo repo.Auth, events repo.Events) *SessionService {
+	return &SessionService{
+		repo:   repo,
+		events: events,
+	}
+}
+
+func (s *SessionService) ListUserSessions(ctx context.Context, userID uuid.UUID) ([]entity.Session, error) {
+	return s.repo.GetSessionsByUser(ctx, userID)
+}
+
+func (s *SessionService) SearchSessions(ctx context.Context, filter entity.SessionFilter) ([]entity.Session, error) {
+	filter.Limit = listLimit(filter.Limit)
+
+	return s.repo.FindSessions(ctx, filter)
+}
+
+// RevokeSessions revokes the active sessions among ids and returns how many were revoked.
+func (s *SessionService) RevokeSessions(ctx context.Context, ids []uuid.UUID) (int64, error) {
+	if len(ids) == 0 {
+		return 0, fmt.Errorf("%w: no session ids", ErrInvalidRequest)
+	}
+	if len(ids) > maxListLimit {
+		return 0, fmt.Errorf("%w: at most %d sessions can be revoked at once", ErrInvalidRequest, maxListLimit)
+	}
+
+	revoked, err := s.repo.RevokeSessions(ctx, ids)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, session := range revoked {
+		recordEvent(ctx, s.events, EventSessionRevoked, session, nil, "", "revoked by administrator")
+	}
+
+	return int64(len(revoked)), nil
+}
+
+// LogoutUser revokes all active sessions of the user.
+func (s *SessionService) LogoutUser(ctx context.Context, userID uuid.UUID) (int64, error) {
+	count, err := s.repo.RevokeUserSessions(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	recordEvent(ctx, s.events, EventLogout, entity.Session{UserId: userID}, nil, "", fmt.Sprintf("revoked %d sessions", count))
+
+	return count, nil
+}
+
+// PurgeExpiredSessions deletes sessions whose refresh token has expired. Such sessions can't
+// be refreshed anymore, so deleting them changes nothing for users.
+func (s *SessionService) PurgeExpiredSessions(ctx context.Context) (int64, error) {
+	return s.repo.DeleteExpiredSessions(ctx, time.Now())
+}
+
+func (s *SessionService) GetUserEvents(ctx context.Context, userID uuid.UUID, limit int) ([]entity.SecurityEvent, error) {
+	return s.events.GetUserEvents(ctx, userID, listLimit(limit))
+}
+
+func (s *SessionService) ListUsers(ctx context.Context, limit int) ([]entity.UserSummary, error) {
+	return s.repo.ListUsers(ctx, listLimit(limit))
+}
+
+func listLimit(limit int) int {
+	switch {
+	case limit <= 0:
+		return defaultListLimit
+	case limit > maxListLimit:
+		return maxListLimit
+	default:
+		return limit
+	}
+}
@@ -2,13 +2,18 @@ package service
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"os"
+	"sync"
 	"time"
 
-	"github.com/sirupsen/logrus"
+	"github.com/BabyJhon/medods-test-task/internal/entity"
+	"github.com/BabyJhon/medods-test-task/internal/logging"
+	"github.com/BabyJhon/medods-test-task/internal/repo"
+	"github.com/gofrs/uuid"
 )
 
 type WebhookPayload struct {
@@ -17,17 +22,95 @@ type WebhookPayload struct {
 	SentAt  string `json:"sent_at"`
 }
 
-func SendWebhook(payload WebhookPayload) error {
+type WebhookService struct {
+	repo repo.Webhooks
+
+	inFlight sync.WaitGroup
+	// sendCtx is cancelled when Drain runs out of time. Deliveries don't use the request
+	// context: a notification shouldn't be lost because the client has gone away.
+	sendCtx    context.Context
+	cancelSend context.CancelFunc
+}
+
+func NewWebhookService(repo repo.Webhooks) *WebhookService {
+	sendCtx, cancelSend := context.WithCancel(context.Background())
+	return &WebhookService{
+		repo:       repo,
+		sendCtx:    sendCtx,
+		cancelSend: cancelSend,
+	}
+}
+
+// SendWebhook posts the payload to WEBHOOK_URL and records the delivery, successful or not,
+// for the admin console.
+func (s *WebhookService) SendWebhook(ctx context.Context, payload WebhookPayload) error {
+	s.inFlight.Add(1)
+	defer s.inFlight.Done()
+
+	url := os.Getenv("WEBHOOK_URL")
+	statusCode, err := sendWebhook(s.sendCtx, url, payload)
+
+	delivery := entity.WebhookDelivery{
+		Event:      payload.Event,
+		Message:    payload.Message,
+		URL:        url,
+		StatusCode: statusCode,
+		CreatedAt:  time.Now(),
+	}
+	if err != nil {
+		delivery.Error = err.Error()
+	}
+	s.recordDelivery(ctx, delivery)
+
+	return err
+}
+
+// Drain waits for the deliveries in flight. Those still running when ctx is done are
+// cancelled. The senders must be stopped first, so no deliveries start meanwhile.
+func (s *WebhookService) Drain(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.inFlight.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.cancelSend()
+		return ctx.Err()
+	}
+}
+
+func (s *WebhookService) ListDeliveries(ctx context.Context, limit int) ([]entity.WebhookDelivery, error) {
+	return s.repo.ListDeliveries(ctx, listLimit(limit))
+}
+
+// recordDelivery only logs errors, a delivery log must not fail the request that sent the webhook.
+func (s *WebhookService) recordDelivery(ctx context.Context, delivery entity.WebhookDelivery) {
+	id, err := uuid.DefaultGenerator.NewV4()
+	if err != nil {
+		logging.FromContext(ctx).Errorf("failed to record webhook delivery: %s", err.Error())
+		return
+	}
+	delivery.ID = id
+
+	if err := s.repo.CreateDelivery(ctx, delivery); err != nil {
+		logging.FromContext(ctx).Errorf("failed to record webhook delivery: %s", err.Error())
+	}
+}
+
+func sendWebhook(ctx context.Context, url string, payload WebhookPayload) (int, error) {
 	payload.SentAt = time.Now().Format(time.RFC3339)
 	jsonData, err := json.Marshal(payload)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	req, err := http.NewRequest("POST", os.Getenv("WEBHOOK_URL"), bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
-		logrus.Info("1")
-		return err
+		return 0, err
 	}
 
 	req.Header.Set("Content-Type", "application/json")
@@ -39,14 +122,13 @@ func SendWebhook(payload WebhookPayload) error {
 
 	resp, err := client.Do(req)
 	if err != nil {
-		logrus.Info("2")
-		return err
+		return 0, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
-		return fmt.Errorf("webhook return status %d", resp.StatusCode)
+		return resp.StatusCode, fmt.Errorf("webhook return status %d", resp.StatusCode)
 	}
 
-	return nil
+	return resp.StatusCode, nil
 }
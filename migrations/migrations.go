@@ -0,0 +1,13 @@
+package migrations
+
+import "embed"
+
+// Postgres holds the goose migrations from this directory.
+//
+//go:embed *.sql
+var Postgres embed.FS
+
+// SQLite migrations mirror the Postgres ones with the same versions.
+//
+//go:embed sqlite/*.sql
+var SQLite embed.FS
@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+-- SQLite не разрешает CURRENT_TIMESTAMP как значение по умолчанию в ADD COLUMN.
+ALTER TABLE authorization_codes ADD COLUMN scope TEXT NOT NULL DEFAULT '';
+ALTER TABLE authorization_codes ADD COLUMN nonce TEXT NOT NULL DEFAULT '';
+ALTER TABLE authorization_codes ADD COLUMN auth_time TIMESTAMP NOT NULL DEFAULT '1970-01-01 00:00:00+00:00';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE authorization_codes DROP COLUMN scope;
+ALTER TABLE authorization_codes DROP COLUMN nonce;
+ALTER TABLE authorization_codes DROP COLUMN auth_time;
+-- +goose StatementEnd
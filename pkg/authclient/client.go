@@ -0,0 +1,190 @@
+// Package authclient is a client for the auth service API. Client calls /auth, /refresh,
+// /user and /revoke of the v1 API, and Transport attaches the access token to outgoing requests and
+// refreshes the token pair when a request gets 401.
+package authclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// apiPath is the root of the API version the client speaks.
+	apiPath = "/api/v1"
+
+	// jsonDelivery asks the service for the tokens in the response body instead of cookies.
+	jsonDelivery = "json"
+
+	defaultUserAgent = "authclient"
+	defaultTimeout   = 10 * time.Second
+)
+
+type Client struct {
+	baseURL    *url.URL
+	httpClient *http.Client
+	userAgent  string
+}
+
+type Option func(*Client)
+
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithUserAgent sets the User-Agent of the auth API requests. The service binds sessions to
+// the user agent and revokes the session on refresh from another one, so it must be stable.
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) {
+		c.userAgent = userAgent
+	}
+}
+
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Client{
+		baseURL:    u,
+		httpClient: &http.Client{Timeout: defaultTimeout},
+		userAgent:  defaultUserAgent,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c, nil
+}
+
+// Auth issues a new token pair for the user. scope is optional and space separated.
+func (c *Client) Auth(ctx context.Context, guid, scope string) (Tokens, error) {
+	query := url.Values{"guid": {guid}, "delivery": {jsonDelivery}}
+	if scope != "" {
+		query.Set("scope", scope)
+	}
+
+	req, err := c.newRequest(ctx, http.MethodGet, "/auth", query)
+	if err != nil {
+		return Tokens{}, err
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return Tokens{}, err
+	}
+	defer resp.Body.Close()
+
+	return decodeTokens(resp)
+}
+
+func (c *Client) Refresh(ctx context.Context, tokens Tokens) (Tokens, error) {
+	req, err := c.newRequest(ctx, http.MethodPost, "/refresh", url.Values{"delivery": {jsonDelivery}})
+	if err != nil {
+		return Tokens{}, err
+	}
+	body, err := json.Marshal(map[string]string{"refresh_token": tokens.RefreshToken})
+	if err != nil {
+		return Tokens{}, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.ContentLength = int64(len(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+tokens.AccessToken)
+
+	resp, err := c.do(req)
+	if err != nil {
+		return Tokens{}, err
+	}
+	defer resp.Body.Close()
+
+	return decodeTokens(resp)
+}
+
+// User returns the GUID of the user the access token was issued to.
+func (c *Client) User(ctx context.Context, accessToken string) (string, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, "/user", nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	resp, err := c.do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var userID string
+	if err := json.NewDecoder(resp.Body).Decode(&userID); err != nil {
+		return "", fmt.Errorf("decode user response: %w", err)
+	}
+
+	return userID, nil
+}
+
+func (c *Client) Revoke(ctx context.Context, accessToken string) error {
+	req, err := c.newRequest(ctx, http.MethodPost, "/revoke", nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	return nil
+}
+
+func (c *Client) newRequest(ctx context.Context, method, path string, query url.Values) (*http.Request, error) {
+	u := c.baseURL.JoinPath(apiPath, path)
+	u.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", c.userAgent)
+
+	return req, nil
+}
+
+// do sends the request and turns non-2xx responses into *Error.
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		return nil, newError(resp)
+	}
+
+	return resp, nil
+}
+
+func decodeTokens(resp *http.Response) (Tokens, error) {
+	var tokens Tokens
+	if err := json.NewDecoder(resp.Body).Decode(&tokens); err != nil {
+		return Tokens{}, fmt.Errorf("decode token response: %w", err)
+	}
+
+	if tokens.AccessToken == "" || tokens.RefreshToken == "" {
+		return Tokens{}, fmt.Errorf("%w: response has no tokens", ErrServer)
+	}
+
+	return tokens, nil
+}
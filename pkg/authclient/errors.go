@@ -0,0 +1,65 @@
+package authclient
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+var (
+	ErrBadRequest   = errors.New("bad request")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+	ErrServer       = errors.New("auth service error")
+	ErrNoTokens     = errors.New("no tokens in store")
+)
+
+// Error is returned for non-2xx responses of the auth API. Code is the stable code of the
+// problem+json response, such as token_expired or refresh_reuse, and Message its title.
+// Use errors.Is with ErrBadRequest, ErrUnauthorized, ErrForbidden or ErrServer to check
+// the kind of the error.
+type Error struct {
+	StatusCode int
+	Code       string
+	Message    string
+}
+
+func (e *Error) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("auth service: %d %s", e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("auth service: %d %s: %s", e.StatusCode, e.Code, e.Message)
+}
+
+func (e *Error) Unwrap() error {
+	switch {
+	case e.StatusCode == http.StatusUnauthorized:
+		return ErrUnauthorized
+	case e.StatusCode == http.StatusForbidden:
+		return ErrForbidden
+	case e.StatusCode >= http.StatusInternalServerError:
+		return ErrServer
+	default:
+		return ErrBadRequest
+	}
+}
+
+func newError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+
+	var problem struct {
+		Code  string `json:"code"`
+		Title string `json:"title"`
+	}
+	if err := json.Unmarshal(body, &problem); err != nil || problem.Title == "" {
+		problem.Title = http.StatusText(resp.StatusCode)
+	}
+
+	return &Error{
+		StatusCode: resp.StatusCode,
+		Code:       problem.Code,
+		Message:    problem.Title,
+	}
+}
@@ -0,0 +1,45 @@
+package authclient
+
+import (
+	"context"
+	"sync"
+)
+
+type Tokens struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// TokenStore keeps the current token pair. Implementations must be safe for concurrent use.
+// Load returns ErrNoTokens when there are no tokens yet.
+type TokenStore interface {
+	Load(ctx context.Context) (Tokens, error)
+	Save(ctx context.Context, tokens Tokens) error
+}
+
+type MemoryStore struct {
+	mu     sync.RWMutex
+	tokens Tokens
+}
+
+func NewMemoryStore(tokens Tokens) *MemoryStore {
+	return &MemoryStore{tokens: tokens}
+}
+
+func (s *MemoryStore) Load(ctx context.Context) (Tokens, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.tokens.AccessToken == "" {
+		return Tokens{}, ErrNoTokens
+	}
+	return s.tokens, nil
+}
+
+func (s *MemoryStore) Save(ctx context.Context, tokens Tokens) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tokens = tokens
+	return nil
+}
@@ -0,0 +1,128 @@
+package authclient
+
+import (
+	"context"
+	"net/http"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// refreshTimeout bounds a shared refresh. It runs detached from the request that started
+// it, so that canceling that request doesn't fail the others waiting for the refresh.
+const refreshTimeout = defaultTimeout
+
+// Transport is an http.RoundTripper that sends requests with the access token from Store.
+// When a request gets 401 it refreshes the token pair once and retries the request.
+// Concurrent requests that fail with the same token share a single refresh.
+//
+// The token is always sent with the Bearer scheme. Transport doesn't create DPoP proofs,
+// so it can't be used with tokens bound to a DPoP key.
+type Transport struct {
+	// Base is the underlying transport. nil means http.DefaultTransport.
+	Base   http.RoundTripper
+	Client *Client
+	Store  TokenStore
+
+	refreshGroup singleflight.Group
+}
+
+func NewTransport(client *Client, store TokenStore) *Transport {
+	return &Transport{
+		Client: client,
+		Store:  store,
+	}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	tokens, err := t.Store.Load(req.Context())
+	if err != nil {
+		closeBody(req)
+		return nil, err
+	}
+
+	resp, err := t.send(req, tokens.AccessToken)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+
+	// The body has already been sent, the request can be retried only if it can be rewound.
+	if req.Body != nil && req.GetBody == nil {
+		return resp, nil
+	}
+
+	refreshed, err := t.refresh(req.Context(), tokens)
+	if err != nil {
+		closeBody(req)
+		return resp, nil
+	}
+	resp.Body.Close()
+
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		if retry.Body, err = req.GetBody(); err != nil {
+			closeBody(req)
+			return nil, err
+		}
+	}
+
+	return t.send(retry, refreshed.AccessToken)
+}
+
+func (t *Transport) send(req *http.Request, accessToken string) (*http.Response, error) {
+	outgoing := req.Clone(req.Context())
+	outgoing.Header.Set("Authorization", "Bearer "+accessToken)
+
+	return t.base().RoundTrip(outgoing)
+}
+
+// refresh refreshes the token pair that the failed request used. If another request has
+// already replaced it in the store, the stored pair is used without refreshing again.
+func (t *Transport) refresh(ctx context.Context, used Tokens) (Tokens, error) {
+	results := t.refreshGroup.DoChan(used.AccessToken, func() (interface{}, error) {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), refreshTimeout)
+		defer cancel()
+
+		current, err := t.Store.Load(ctx)
+		if err != nil {
+			return Tokens{}, err
+		}
+		if current.AccessToken != used.AccessToken {
+			return current, nil
+		}
+
+		refreshed, err := t.Client.Refresh(ctx, current)
+		if err != nil {
+			return Tokens{}, err
+		}
+
+		if err := t.Store.Save(ctx, refreshed); err != nil {
+			return Tokens{}, err
+		}
+
+		return refreshed, nil
+	})
+
+	select {
+	case result := <-results:
+		if result.Err != nil {
+			return Tokens{}, result.Err
+		}
+		return result.Val.(Tokens), nil
+	case <-ctx.Done():
+		return Tokens{}, ctx.Err()
+	}
+}
+
+func (t *Transport) base() http.RoundTripper {
+	if t.Base != nil {
+		return t.Base
+	}
+	return http.DefaultTransport
+}
+
+// closeBody closes the request body, which RoundTrip must do even when it fails.
+func closeBody(req *http.Request) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+}
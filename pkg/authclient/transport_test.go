@@ -0,0 +1,194 @@
+package authclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+var (
+	oldTokens = Tokens{AccessToken: "access-1", RefreshToken: "refresh-1"}
+	newTokens = Tokens{AccessToken: "access-2", RefreshToken: "refresh-2"}
+)
+
+// newTestServer serves /refresh, which rotates oldTokens into newTokens, and /resource,
+// which accepts only the new access token and echoes the request body.
+func newTestServer(t *testing.T, refreshes *atomic.Int32) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST "+apiPath+"/refresh", func(w http.ResponseWriter, r *http.Request) {
+		refreshes.Add(1)
+		var body struct {
+			RefreshToken string `json:"refresh_token"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.RefreshToken != oldTokens.RefreshToken {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(newTokens)
+	})
+	mux.HandleFunc("/resource", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+newTokens.AccessToken {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		io.Copy(w, r.Body)
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newTestTransport(t *testing.T, server *httptest.Server, store TokenStore) *Transport {
+	t.Helper()
+
+	client, err := New(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewTransport(client, store)
+}
+
+// onceReader is a body that can't be rewound.
+type onceReader struct {
+	io.Reader
+	closed bool
+}
+
+func (r *onceReader) Close() error {
+	r.closed = true
+	return nil
+}
+
+func TestTransportRefreshesOn401(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      func() (io.Reader, *onceReader)
+		status    int
+		refreshes int32
+	}{
+		{
+			name:      "no body",
+			body:      func() (io.Reader, *onceReader) { return nil, nil },
+			status:    http.StatusOK,
+			refreshes: 1,
+		},
+		{
+			name:      "body with GetBody",
+			body:      func() (io.Reader, *onceReader) { return strings.NewReader("payload"), nil },
+			status:    http.StatusOK,
+			refreshes: 1,
+		},
+		{
+			name: "body without GetBody",
+			body: func() (io.Reader, *onceReader) {
+				body := &onceReader{Reader: strings.NewReader("payload")}
+				return body, body
+			},
+			status: http.StatusUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var refreshes atomic.Int32
+			server := newTestServer(t, &refreshes)
+			store := NewMemoryStore(oldTokens)
+			transport := newTestTransport(t, server, store)
+
+			body, once := tt.body()
+			req, err := http.NewRequest(http.MethodPost, server.URL+"/resource", body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := transport.RoundTrip(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.status {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.status)
+			}
+			if got := refreshes.Load(); got != tt.refreshes {
+				t.Errorf("refreshes = %d, want %d", got, tt.refreshes)
+			}
+			if body != nil && tt.status == http.StatusOK {
+				got, _ := io.ReadAll(resp.Body)
+				if string(got) != "payload" {
+					t.Errorf("retried body = %q, want %q", got, "payload")
+				}
+			}
+			if once != nil && !once.closed {
+				t.Error("request body is not closed")
+			}
+
+			stored, _ := store.Load(context.Background())
+			want := oldTokens
+			if tt.refreshes > 0 {
+				want = newTokens
+			}
+			if stored != want {
+				t.Errorf("stored tokens = %+v, want %+v", stored, want)
+			}
+		})
+	}
+}
+
+func TestTransportSharesRefresh(t *testing.T) {
+	var refreshes atomic.Int32
+	server := newTestServer(t, &refreshes)
+	transport := newTestTransport(t, server, NewMemoryStore(oldTokens))
+	httpClient := &http.Client{Transport: transport}
+
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := httpClient.Post(server.URL+"/resource", "text/plain", bytes.NewReader([]byte("payload")))
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusOK)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// The server treats a second use of the old refresh token as reuse, so more than one
+	// refresh would have failed some of the requests above.
+	if got := refreshes.Load(); got != 1 {
+		t.Errorf("refreshes = %d, want 1", got)
+	}
+}
+
+func TestTransportClosesBodyOnError(t *testing.T) {
+	var refreshes atomic.Int32
+	server := newTestServer(t, &refreshes)
+	transport := newTestTransport(t, server, NewMemoryStore(Tokens{}))
+
+	body := &onceReader{Reader: strings.NewReader("payload")}
+	req, err := http.NewRequest(http.MethodPost, server.URL+"/resource", body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := transport.RoundTrip(req); !errors.Is(err, ErrNoTokens) {
+		t.Fatalf("got %v, want %v", err, ErrNoTokens)
+	}
+	if !body.closed {
+		t.Error("request body is not closed")
+	}
+}
@@ -0,0 +1,310 @@
+// Package authmw verifies access tokens issued by the auth service in downstream
+// services. It provides middleware for net/http and gin that extracts the token from the
+// Authorization header or a cookie, verifies it and puts *Claims into the request context.
+//
+// The service signs access tokens with RS256 and the key published in its JWKS, so
+// downstream services verify them with JWKSURL set to <ISSUER_URL>/.well-known/jwks.json
+// and don't need any secret. HMACKey is only for HS512 tokens issued with the shared
+// SIGNING_KEY before the switch to published keys.
+package authmw
+
+import (
+	"context"
+	"crypto"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/BabyJhon/medods-test-task/pkg/dpop"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	DefaultCookieName = "access_token"
+
+	// accessTokenType is the typ header of access tokens (RFC 9068).
+	accessTokenType = "at+jwt"
+
+	defaultHTTPTimeout = 10 * time.Second
+)
+
+var (
+	ErrNoToken      = errors.New("access token is missing")
+	ErrInvalidToken = errors.New("access token is invalid")
+	ErrTokenRevoked = errors.New("access token is revoked")
+	// ErrCertificateMismatch means a certificate-bound token was presented without the
+	// client certificate it is bound to.
+	ErrCertificateMismatch = errors.New("access token is bound to another client certificate")
+	// ErrDPoPProof means a DPoP-bound token was presented without a valid proof of
+	// possession of its key, or with a key it is not bound to.
+	ErrDPoPProof = dpop.ErrInvalidProof
+)
+
+type Config struct {
+	// HMACKey verifies HS256/HS384/HS512 tokens signed with the shared SIGNING_KEY. The
+	// service no longer issues them, so it is only needed for tokens issued before that.
+	HMACKey []byte
+	// PublicKey verifies RS*/ES* tokens with a single known key. Such tokens must have the
+	// at+jwt typ header, so ID tokens signed with the same key are rejected.
+	PublicKey crypto.PublicKey
+	// JWKSURL verifies RS*/ES* tokens with keys fetched from the JWK Set, selected by kid,
+	// with the same typ requirement. This is how access tokens of the auth service are
+	// verified.
+	JWKSURL string
+	// JWKSRefreshInterval is how long fetched keys are trusted before they are fetched again.
+	JWKSRefreshInterval time.Duration
+
+	// CookieName is checked when there is no Authorization header. Empty means DefaultCookieName.
+	CookieName string
+	// DisableCookie makes the middleware accept only the Authorization header.
+	DisableCookie bool
+
+	// ClientCertificate returns the client certificate of the request for certificate-bound
+	// tokens. Empty means the certificate of the request's own TLS connection; services
+	// behind a TLS-terminating proxy set it to read the certificate the proxy forwards.
+	ClientCertificate func(r *http.Request) *x509.Certificate
+
+	// DPoPRequestURL returns the URL DPoP proofs are checked against. Empty means the URL
+	// of the request as this service sees it; services behind a proxy set it to return the
+	// public URL the client signed, for example with dpop.ExternalURL.
+	DPoPRequestURL func(r *http.Request) string
+	// DPoPReplayCache rejects reused DPoP proofs. Empty means an in-memory cache.
+	DPoPReplayCache dpop.ReplayCache
+
+	// Introspection enables the revocation check against the introspection endpoint.
+	Introspection *IntrospectionConfig
+
+	// HTTPClient is used for JWKS and introspection requests.
+	HTTPClient *http.Client
+}
+
+type Verifier struct {
+	cfg          Config
+	jwks         *jwksCache
+	introspector *introspector
+	dpop         *dpop.Verifier
+	methods      []string
+}
+
+func NewVerifier(cfg Config) (*Verifier, error) {
+	if cfg.HMACKey == nil && cfg.PublicKey == nil && cfg.JWKSURL == "" {
+		return nil, errors.New("one of HMACKey, PublicKey or JWKSURL is required")
+	}
+	if cfg.CookieName == "" {
+		cfg.CookieName = DefaultCookieName
+	}
+	if cfg.ClientCertificate == nil {
+		cfg.ClientCertificate = tlsClientCertificate
+	}
+	if cfg.DPoPRequestURL == nil {
+		cfg.DPoPRequestURL = dpop.RequestURL
+	}
+	if cfg.DPoPReplayCache == nil {
+		cfg.DPoPReplayCache = dpop.NewMemoryReplayCache()
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{Timeout: defaultHTTPTimeout}
+	}
+
+	v := &Verifier{
+		cfg:  cfg,
+		dpop: &dpop.Verifier{Replay: cfg.DPoPReplayCache},
+	}
+
+	if cfg.HMACKey != nil {
+		v.methods = append(v.methods, "HS256", "HS384", "HS512")
+	}
+	if cfg.PublicKey != nil || cfg.JWKSURL != "" {
+		v.methods = append(v.methods, "RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512")
+	}
+	if cfg.JWKSURL != "" {
+		v.jwks = newJWKSCache(cfg.JWKSURL, cfg.JWKSRefreshInterval, cfg.HTTPClient)
+	}
+	if cfg.Introspection != nil {
+		introspector, err := newIntrospector(*cfg.Introspection, cfg.HTTPClient)
+		if err != nil {
+			return nil, err
+		}
+		v.introspector = introspector
+	}
+
+	return v, nil
+}
+
+// Verify checks the token signature and expiration and, if introspection is configured,
+// that the token has not been revoked.
+func (v *Verifier) Verify(ctx context.Context, token string) (*Claims, error) {
+	claims := &Claims{}
+
+	_, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		return v.key(ctx, t)
+	}, jwt.WithValidMethods(v.methods), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+
+	if v.introspector != nil {
+		active, err := v.introspector.active(ctx, token, claims)
+		if err != nil {
+			return nil, err
+		}
+		if !active {
+			return nil, ErrTokenRevoked
+		}
+	}
+
+	return claims, nil
+}
+
+func (v *Verifier) key(ctx context.Context, t *jwt.Token) (interface{}, error) {
+	typ, _ := t.Header["typ"].(string)
+	accessToken := strings.EqualFold(typ, accessTokenType) || strings.EqualFold(typ, "application/"+accessTokenType)
+
+	// HMAC ключом подписываются только access токены, в том числе выданные до появления typ.
+	// Открытым ключом подписываются и ID токены, поэтому у таких токенов typ обязателен.
+	if _, ok := t.Method.(*jwt.SigningMethodHMAC); ok {
+		return v.cfg.HMACKey, nil
+	}
+	if !accessToken {
+		return nil, fmt.Errorf("typ must be %s, got %q", accessTokenType, typ)
+	}
+
+	if v.jwks != nil {
+		kid, _ := t.Header["kid"].(string)
+		if kid != "" || v.cfg.PublicKey == nil {
+			return v.jwks.key(ctx, kid)
+		}
+	}
+
+	if v.cfg.PublicKey == nil {
+		return nil, errors.New("no key to verify the token")
+	}
+	return v.cfg.PublicKey, nil
+}
+
+// TokenFromRequest returns the Bearer or DPoP token from the Authorization header or,
+// unless cookies are disabled, the access token cookie.
+func (v *Verifier) TokenFromRequest(r *http.Request) (string, error) {
+	token, _, err := v.tokenFromRequest(r)
+	return token, err
+}
+
+func (v *Verifier) tokenFromRequest(r *http.Request) (string, string, error) {
+	if header := r.Header.Get("Authorization"); header != "" {
+		scheme, token, found := strings.Cut(header, " ")
+		if !found || !(strings.EqualFold(scheme, "Bearer") || strings.EqualFold(scheme, dpop.Scheme)) || token == "" {
+			return "", "", fmt.Errorf("%w: malformed Authorization header", ErrInvalidToken)
+		}
+		return token, scheme, nil
+	}
+
+	if !v.cfg.DisableCookie {
+		if cookie, err := r.Cookie(v.cfg.CookieName); err == nil && cookie.Value != "" {
+			return cookie.Value, "", nil
+		}
+	}
+
+	return "", "", ErrNoToken
+}
+
+// verifyRequest is shared by the net/http and gin middleware.
+func (v *Verifier) verifyRequest(r *http.Request) (*Claims, int, error) {
+	token, scheme, err := v.tokenFromRequest(r)
+	if err != nil {
+		return nil, http.StatusUnauthorized, err
+	}
+
+	claims, err := v.Verify(r.Context(), token)
+	if err != nil {
+		if errors.Is(err, ErrInvalidToken) || errors.Is(err, ErrTokenRevoked) {
+			return nil, http.StatusUnauthorized, err
+		}
+		return nil, http.StatusServiceUnavailable, err
+	}
+
+	if err := v.verifyCertificateBinding(r, claims); err != nil {
+		return nil, http.StatusUnauthorized, err
+	}
+	if err := v.verifyDPoP(r, scheme, token, claims); err != nil {
+		return nil, http.StatusUnauthorized, err
+	}
+
+	return claims, http.StatusOK, nil
+}
+
+// verifyCertificateBinding rejects a certificate-bound token unless the request comes with
+// the same client certificate. Unbound tokens are accepted as is.
+func (v *Verifier) verifyCertificateBinding(r *http.Request, claims *Claims) error {
+	if claims.Confirmation == nil || claims.Confirmation.X5tS256 == "" {
+		return nil
+	}
+
+	cert := v.cfg.ClientCertificate(r)
+	if cert == nil {
+		return ErrCertificateMismatch
+	}
+
+	sum := sha256.Sum256(cert.Raw)
+	thumbprint := base64.RawURLEncoding.EncodeToString(sum[:])
+	if subtle.ConstantTimeCompare([]byte(thumbprint), []byte(claims.Confirmation.X5tS256)) != 1 {
+		return ErrCertificateMismatch
+	}
+
+	return nil
+}
+
+// verifyDPoP requires a DPoP-bound token to be sent with the DPoP scheme and a proof signed
+// with its key for this request and this token. The DPoP scheme with an unbound token is
+// rejected too, since the client evidently expects a binding that isn't there.
+func (v *Verifier) verifyDPoP(r *http.Request, scheme, token string, claims *Claims) error {
+	bound := claims.Confirmation != nil && claims.Confirmation.JKT != ""
+	if !strings.EqualFold(scheme, dpop.Scheme) {
+		if bound {
+			return fmt.Errorf("%w: dpop-bound token requires the DPoP scheme", ErrDPoPProof)
+		}
+		return nil
+	}
+	if !bound {
+		return fmt.Errorf("%w: access token is not dpop-bound", ErrInvalidToken)
+	}
+
+	proof, err := dpop.FromRequest(r)
+	if err != nil {
+		return err
+	}
+	if proof == "" {
+		return fmt.Errorf("%w: DPoP header is required", ErrDPoPProof)
+	}
+
+	verified, err := v.dpop.Verify(proof, r.Method, v.cfg.DPoPRequestURL(r), token)
+	if err != nil {
+		return err
+	}
+	if subtle.ConstantTimeCompare([]byte(verified.JKT), []byte(claims.Confirmation.JKT)) != 1 {
+		return fmt.Errorf("%w: proof is signed with another key", ErrDPoPProof)
+	}
+
+	return nil
+}
+
+// wwwAuthenticate returns the challenge for a 401 response, pointing DPoP clients at the
+// proof rather than the token.
+func wwwAuthenticate(err error) string {
+	if errors.Is(err, ErrDPoPProof) {
+		return fmt.Sprintf(`DPoP error="invalid_dpop_proof", algs="%s"`, strings.Join(dpop.SigningAlgorithms(), " "))
+	}
+	return `Bearer error="invalid_token"`
+}
+
+func tlsClientCertificate(r *http.Request) *x509.Certificate {
+	if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+		return nil
+	}
+	return r.TLS.PeerCertificates[0]
+}
@@ -0,0 +1,67 @@
+package authmw
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestVerifyTokenType(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hmacKey := []byte("secret")
+
+	v, err := NewVerifier(Config{HMACKey: hmacKey, PublicKey: &rsaKey.PublicKey})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sign := func(method jwt.SigningMethod, key any, typ string) string {
+		token := jwt.NewWithClaims(method, jwt.MapClaims{
+			"sub": "user",
+			"aud": "client",
+			"exp": time.Now().Add(time.Minute).Unix(),
+		})
+		if typ == "" {
+			delete(token.Header, "typ")
+		} else {
+			token.Header["typ"] = typ
+		}
+		signed, err := token.SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		valid bool
+	}{
+		{"RS256 access token", sign(jwt.SigningMethodRS256, rsaKey, "at+jwt"), true},
+		{"RS256 application/at+jwt", sign(jwt.SigningMethodRS256, rsaKey, "application/at+jwt"), true},
+		{"RS256 ID token", sign(jwt.SigningMethodRS256, rsaKey, "JWT"), false},
+		{"RS256 without typ", sign(jwt.SigningMethodRS256, rsaKey, ""), false},
+		{"HS512 access token", sign(jwt.SigningMethodHS512, hmacKey, "at+jwt"), true},
+		{"HS512 token issued before typ", sign(jwt.SigningMethodHS512, hmacKey, "JWT"), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := v.Verify(context.Background(), tt.token)
+			if tt.valid && err != nil {
+				t.Fatalf("Verify: %v", err)
+			}
+			if !tt.valid && !errors.Is(err, ErrInvalidToken) {
+				t.Fatalf("Verify: got %v, want ErrInvalidToken", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package authmw
+
+import (
+	"context"
+	"net"
+	"slices"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Claims mirrors the access token claims issued by the auth service.
+type Claims struct {
+	SessionID string   `json:"session_id,omitempty"`
+	UserAgent string   `json:"user_agent,omitempty"`
+	IP        net.IP   `json:"ip,omitempty"`
+	ClientID  string   `json:"client_id,omitempty"`
+	Scope     string   `json:"scope,omitempty"`
+	Roles     []string `json:"roles,omitempty"`
+	// Confirmation is set for tokens bound to the client's TLS certificate or DPoP key.
+	Confirmation *Confirmation `json:"cnf,omitempty"`
+	jwt.RegisteredClaims
+}
+
+type Confirmation struct {
+	// X5tS256 is the base64url SHA-256 thumbprint of the client certificate (RFC 8705).
+	X5tS256 string `json:"x5t#S256,omitempty"`
+	// JKT is the JWK SHA-256 thumbprint of the client's DPoP key (RFC 9449).
+	JKT string `json:"jkt,omitempty"`
+}
+
+func (c *Claims) HasScope(scope string) bool {
+	return slices.Contains(strings.Fields(c.Scope), scope)
+}
+
+func (c *Claims) HasRole(role string) bool {
+	return slices.Contains(c.Roles, role)
+}
+
+type claimsCtxKey struct{}
+
+func NewContext(ctx context.Context, claims *Claims) context.Context {
+	return context.WithValue(ctx, claimsCtxKey{}, claims)
+}
+
+func FromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(claimsCtxKey{}).(*Claims)
+	return claims, ok
+}
@@ -0,0 +1,53 @@
+package authmw
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GinClaimsKey is the gin context key the claims are stored under, in addition to the
+// request context.
+const GinClaimsKey = "authmw.claims"
+
+func (v *Verifier) Gin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, status, err := v.verifyRequest(c.Request)
+		if err != nil {
+			if status == http.StatusUnauthorized {
+				c.Header("WWW-Authenticate", wwwAuthenticate(err))
+			}
+			c.AbortWithStatusJSON(status, errorResponse{Message: err.Error()})
+			return
+		}
+
+		c.Set(GinClaimsKey, claims)
+		c.Request = c.Request.WithContext(NewContext(c.Request.Context(), claims))
+		c.Next()
+	}
+}
+
+// GinRequireScopes must be used after Gin. It allows the request only if the token
+// carries all of the scopes.
+func GinRequireScopes(scopes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := GinClaims(c)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse{Message: ErrNoToken.Error()})
+			return
+		}
+
+		for _, scope := range scopes {
+			if !claims.HasScope(scope) {
+				c.AbortWithStatusJSON(http.StatusForbidden, errorResponse{Message: "scope " + scope + " is required"})
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
+func GinClaims(c *gin.Context) (*Claims, bool) {
+	return FromContext(c.Request.Context())
+}
@@ -0,0 +1,59 @@
+package authmw
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+type errorResponse struct {
+	Message string `json:"message"`
+}
+
+// Middleware verifies the token of every request and calls next with the claims in the
+// request context. Requests without a valid token get 401.
+func (v *Verifier) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, status, err := v.verifyRequest(r)
+		if err != nil {
+			if status == http.StatusUnauthorized {
+				w.Header().Set("WWW-Authenticate", wwwAuthenticate(err))
+			}
+			writeError(w, status, err.Error())
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), claims)))
+	})
+}
+
+// RequireScopes must be used after Middleware. It allows the request only if the token
+// carries all of the scopes.
+func RequireScopes(scopes ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := FromContext(r.Context())
+			if !ok {
+				writeError(w, http.StatusUnauthorized, ErrNoToken.Error())
+				return
+			}
+
+			for _, scope := range scopes {
+				if !claims.HasScope(scope) {
+					writeError(w, http.StatusForbidden, "scope "+scope+" is required")
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	if status == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") == "" {
+		w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(errorResponse{Message: message})
+}
@@ -0,0 +1,126 @@
+package authmw
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultIntrospectionCacheTTL = 30 * time.Second
+	maxIntrospectionCacheSize    = 10000
+)
+
+type IntrospectionConfig struct {
+	// URL of the /oauth/introspect endpoint.
+	URL string
+	// ClientID and ClientSecret of a confidential client, sent with HTTP Basic.
+	ClientID     string
+	ClientSecret string
+	// CacheTTL is how long an introspection result is reused. A revoked token may be
+	// accepted for up to CacheTTL after revocation.
+	CacheTTL time.Duration
+}
+
+type introspectionResult struct {
+	active    bool
+	expiresAt time.Time
+}
+
+type introspector struct {
+	cfg    IntrospectionConfig
+	client *http.Client
+
+	mu    sync.Mutex
+	cache map[[sha256.Size]byte]introspectionResult
+}
+
+func newIntrospector(cfg IntrospectionConfig, client *http.Client) (*introspector, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("introspection URL is required")
+	}
+	if cfg.CacheTTL <= 0 {
+		cfg.CacheTTL = defaultIntrospectionCacheTTL
+	}
+
+	return &introspector{
+		cfg:    cfg,
+		client: client,
+		cache:  make(map[[sha256.Size]byte]introspectionResult),
+	}, nil
+}
+
+func (i *introspector) active(ctx context.Context, token string, claims *Claims) (bool, error) {
+	key := sha256.Sum256([]byte(token))
+	now := time.Now()
+
+	i.mu.Lock()
+	result, ok := i.cache[key]
+	i.mu.Unlock()
+	if ok && now.Before(result.expiresAt) {
+		return result.active, nil
+	}
+
+	active, err := i.introspect(ctx, token)
+	if err != nil {
+		return false, err
+	}
+
+	expiresAt := now.Add(i.cfg.CacheTTL)
+	if claims.ExpiresAt != nil && claims.ExpiresAt.Before(expiresAt) {
+		expiresAt = claims.ExpiresAt.Time
+	}
+
+	i.mu.Lock()
+	if len(i.cache) >= maxIntrospectionCacheSize {
+		for k, v := range i.cache {
+			if !now.Before(v.expiresAt) {
+				delete(i.cache, k)
+			}
+		}
+		if len(i.cache) >= maxIntrospectionCacheSize {
+			clear(i.cache)
+		}
+	}
+	i.cache[key] = introspectionResult{active: active, expiresAt: expiresAt}
+	i.mu.Unlock()
+
+	return active, nil
+}
+
+func (i *introspector) introspect(ctx context.Context, token string) (bool, error) {
+	form := url.Values{"token": {token}}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, i.cfg.URL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(i.cfg.ClientID, i.cfg.ClientSecret)
+
+	resp, err := i.client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("introspect token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("introspect token: status %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Active bool `json:"active"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return false, fmt.Errorf("decode introspection response: %w", err)
+	}
+
+	return body.Active, nil
+}
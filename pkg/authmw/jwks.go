@@ -0,0 +1,140 @@
+package authmw
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/BabyJhon/medods-test-task/pkg/jwk"
+)
+
+const (
+	defaultJWKSRefreshInterval = time.Hour
+	// minJWKSRefetchInterval limits refetching on unknown kid, so tokens with random kids
+	// can't make us hammer the JWKS endpoint.
+	minJWKSRefetchInterval = time.Minute
+	jwksFetchTimeout       = 10 * time.Second
+)
+
+type jwksCache struct {
+	url             string
+	refreshInterval time.Duration
+	client          *http.Client
+
+	// fetchMu serializes fetches. It is held during the request, unlike mu, so
+	// verifications with cached keys don't wait for a slow JWKS endpoint.
+	fetchMu sync.Mutex
+
+	mu        sync.RWMutex
+	keys      jwk.Set
+	fetchedAt time.Time
+	// triedAt and fetchErr are the time and result of the last fetch, successful or not.
+	triedAt  time.Time
+	fetchErr error
+}
+
+func newJWKSCache(url string, refreshInterval time.Duration, client *http.Client) *jwksCache {
+	if refreshInterval <= 0 {
+		refreshInterval = defaultJWKSRefreshInterval
+	}
+
+	return &jwksCache{
+		url:             url,
+		refreshInterval: refreshInterval,
+		client:          client,
+	}
+}
+
+// key returns the key by kid. Keys that are due for refresh are still used if the refresh
+// fails: the JWKS endpoint being down doesn't make them any less valid.
+func (c *jwksCache) key(ctx context.Context, kid string) (interface{}, error) {
+	c.mu.RLock()
+	fetchedAt, triedAt := c.fetchedAt, c.triedAt
+	c.mu.RUnlock()
+
+	if fetchedAt.IsZero() || (time.Since(fetchedAt) > c.refreshInterval && time.Since(triedAt) > minJWKSRefetchInterval) {
+		if err := c.refresh(ctx, triedAt); err != nil && fetchedAt.IsZero() {
+			return nil, err
+		}
+	}
+
+	key, ok, triedAt := c.find(kid)
+	if !ok && time.Since(triedAt) > minJWKSRefetchInterval {
+		if err := c.refresh(ctx, triedAt); err != nil {
+			return nil, fmt.Errorf("key %q not found in JWKS: %w", kid, err)
+		}
+		key, ok, _ = c.find(kid)
+	}
+	if !ok {
+		return nil, fmt.Errorf("key %q not found in JWKS", kid)
+	}
+
+	return key.PublicKey()
+}
+
+func (c *jwksCache) find(kid string) (jwk.Key, bool, time.Time) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if kid == "" && len(c.keys.Keys) == 1 {
+		return c.keys.Keys[0], true, c.triedAt
+	}
+	key, ok := c.keys.Find(kid)
+	return key, ok, c.triedAt
+}
+
+// refresh fetches the keys unless another caller has tried since triedAt, in which case
+// it shares that result. The fetch isn't bound to the cancellation of ctx, since the
+// callers waiting for it would fail with the request that started it.
+func (c *jwksCache) refresh(ctx context.Context, triedAt time.Time) error {
+	c.fetchMu.Lock()
+	defer c.fetchMu.Unlock()
+
+	c.mu.RLock()
+	lastTriedAt, lastErr := c.triedAt, c.fetchErr
+	c.mu.RUnlock()
+	if lastTriedAt.After(triedAt) {
+		return lastErr
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), jwksFetchTimeout)
+	defer cancel()
+	keys, err := c.fetch(ctx)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.triedAt = time.Now()
+	c.fetchErr = err
+	if err == nil {
+		c.keys = keys
+		c.fetchedAt = c.triedAt
+	}
+	return err
+}
+
+func (c *jwksCache) fetch(ctx context.Context) (jwk.Set, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url, nil)
+	if err != nil {
+		return jwk.Set{}, err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return jwk.Set{}, fmt.Errorf("fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return jwk.Set{}, fmt.Errorf("fetch JWKS: status %d", resp.StatusCode)
+	}
+
+	var keys jwk.Set
+	if err := json.NewDecoder(resp.Body).Decode(&keys); err != nil {
+		return jwk.Set{}, fmt.Errorf("decode JWKS: %w", err)
+	}
+
+	return keys, nil
+}
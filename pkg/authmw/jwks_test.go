@@ -0,0 +1,146 @@
+package authmw
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/BabyJhon/medods-test-task/pkg/jwk"
+)
+
+// jwksServer serves a JWK Set with one key and counts the requests. Setting failing makes
+// it answer 500.
+type jwksServer struct {
+	*httptest.Server
+	requests atomic.Int32
+	failing  atomic.Bool
+	delay    time.Duration
+}
+
+func newJWKSServer(t *testing.T, kid string) *jwksServer {
+	t.Helper()
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := jwk.FromPublicKey(&privateKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key.Kid = kid
+
+	s := &jwksServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.requests.Add(1)
+		time.Sleep(s.delay)
+		if s.failing.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(jwk.Set{Keys: []jwk.Key{key}})
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestJWKSCacheKeepsKeysWhenRefreshFails(t *testing.T) {
+	server := newJWKSServer(t, "k1")
+	cache := newJWKSCache(server.URL, time.Hour, server.Client())
+	ctx := context.Background()
+
+	if _, err := cache.key(ctx, "k1"); err != nil {
+		t.Fatalf("key: %v", err)
+	}
+
+	server.failing.Store(true)
+	cache.mu.Lock()
+	cache.fetchedAt = cache.fetchedAt.Add(-2 * time.Hour)
+	cache.triedAt = cache.triedAt.Add(-2 * time.Hour)
+	cache.mu.Unlock()
+
+	if _, err := cache.key(ctx, "k1"); err != nil {
+		t.Fatalf("key after failed refresh: %v", err)
+	}
+	if got := server.requests.Load(); got != 2 {
+		t.Fatalf("got %d JWKS requests, want 2", got)
+	}
+
+	// Неудачное обновление не повторяется на каждом запросе.
+	if _, err := cache.key(ctx, "k1"); err != nil {
+		t.Fatalf("key: %v", err)
+	}
+	if got := server.requests.Load(); got != 2 {
+		t.Fatalf("got %d JWKS requests, want 2", got)
+	}
+}
+
+func TestJWKSCacheFetchesOnceForConcurrentRequests(t *testing.T) {
+	server := newJWKSServer(t, "k1")
+	server.delay = 100 * time.Millisecond
+	cache := newJWKSCache(server.URL, time.Hour, server.Client())
+
+	// Первый запрос отменяется, но ожидающие его загрузки запросы получают ключи.
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		ctx := context.Background()
+		if i == 0 {
+			ctx = cancelled
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := cache.key(ctx, "k1")
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("key: %v", err)
+		}
+	}
+	if got := server.requests.Load(); got != 1 {
+		t.Fatalf("got %d JWKS requests, want 1", got)
+	}
+}
+
+func TestJWKSCacheServesCachedKeysDuringFetch(t *testing.T) {
+	server := newJWKSServer(t, "k1")
+	cache := newJWKSCache(server.URL, time.Hour, server.Client())
+	ctx := context.Background()
+
+	if _, err := cache.key(ctx, "k1"); err != nil {
+		t.Fatalf("key: %v", err)
+	}
+
+	// Загрузка по неизвестному kid не блокирует проверку известными ключами.
+	cache.fetchMu.Lock()
+	defer cache.fetchMu.Unlock()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := cache.key(ctx, "k1")
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("key: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("key with a cached kid waited for the fetch")
+	}
+}
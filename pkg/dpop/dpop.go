@@ -0,0 +1,257 @@
+// Package dpop verifies DPoP proofs (RFC 9449): JWTs signed with a key the client holds,
+// proving that the client sending a request is the one the tokens were issued to.
+package dpop
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BabyJhon/medods-test-task/pkg/jwk"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	HeaderName = "DPoP"
+	Scheme     = "DPoP"
+
+	proofType = "dpop+jwt"
+
+	DefaultMaxAge = time.Minute
+	DefaultLeeway = 5 * time.Second
+)
+
+var (
+	ErrInvalidProof = errors.New("invalid dpop proof")
+	// ErrReplayedProof is wrapped together with ErrInvalidProof.
+	ErrReplayedProof = errors.New("proof has already been used")
+)
+
+// signingMethods are the asymmetric algorithms accepted for proofs. Symmetric algorithms
+// would need a secret shared with the server, which defeats proof of possession.
+var signingMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+
+// ReplayCache remembers the jti of accepted proofs so each proof is accepted only once.
+type ReplayCache interface {
+	// Seen records key until expiresAt and reports whether it was already recorded.
+	Seen(key string, expiresAt time.Time) bool
+}
+
+type Verifier struct {
+	// MaxAge is how old a proof may be, DefaultMaxAge if zero.
+	MaxAge time.Duration
+	// Leeway allows for clock skew between client and server, DefaultLeeway if zero.
+	Leeway time.Duration
+	// Replay rejects proofs that have been seen before. It must outlive MaxAge + Leeway.
+	Replay ReplayCache
+}
+
+type Proof struct {
+	// JKT is the JWK SHA-256 thumbprint of the proof key, the cnf.jkt claim of bound tokens.
+	JKT      string
+	JTI      string
+	IssuedAt time.Time
+}
+
+type proofClaims struct {
+	JTI string           `json:"jti"`
+	HTM string           `json:"htm"`
+	HTU string           `json:"htu"`
+	IAT *jwt.NumericDate `json:"iat"`
+	// ATH is the base64url SHA-256 hash of the access token, required when a proof
+	// accompanies an access token.
+	ATH string `json:"ath,omitempty"`
+}
+
+func (c proofClaims) GetExpirationTime() (*jwt.NumericDate, error) { return nil, nil }
+func (c proofClaims) GetIssuedAt() (*jwt.NumericDate, error)       { return c.IAT, nil }
+func (c proofClaims) GetNotBefore() (*jwt.NumericDate, error)      { return nil, nil }
+func (c proofClaims) GetIssuer() (string, error)                   { return "", nil }
+func (c proofClaims) GetSubject() (string, error)                  { return "", nil }
+func (c proofClaims) GetAudience() (jwt.ClaimStrings, error)       { return nil, nil }
+
+// Verify checks the proof for a request with the given method and URL. accessToken is
+// empty at the token endpoint and the presented token everywhere else.
+func (v *Verifier) Verify(proof, method, requestURL, accessToken string) (*Proof, error) {
+	maxAge, leeway := v.MaxAge, v.Leeway
+	if maxAge == 0 {
+		maxAge = DefaultMaxAge
+	}
+	if leeway == 0 {
+		leeway = DefaultLeeway
+	}
+
+	var (
+		claims proofClaims
+		key    jwk.Key
+	)
+	_, err := jwt.ParseWithClaims(proof, &claims, func(t *jwt.Token) (interface{}, error) {
+		if typ, _ := t.Header["typ"].(string); typ != proofType {
+			return nil, fmt.Errorf("typ must be %s", proofType)
+		}
+
+		raw, ok := t.Header["jwk"].(map[string]any)
+		if !ok {
+			return nil, errors.New("jwk header is required")
+		}
+		if _, ok := raw["d"]; ok {
+			return nil, errors.New("jwk header must not contain a private key")
+		}
+		data, err := json.Marshal(raw)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &key); err != nil {
+			return nil, err
+		}
+
+		return key.PublicKey()
+	}, jwt.WithValidMethods(signingMethods))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidProof, err)
+	}
+
+	if claims.JTI == "" {
+		return nil, fmt.Errorf("%w: jti is required", ErrInvalidProof)
+	}
+	if claims.HTM != method {
+		return nil, fmt.Errorf("%w: htm does not match the request method", ErrInvalidProof)
+	}
+	if !sameURL(claims.HTU, requestURL) {
+		return nil, fmt.Errorf("%w: htu does not match the request url", ErrInvalidProof)
+	}
+
+	if claims.IAT == nil {
+		return nil, fmt.Errorf("%w: iat is required", ErrInvalidProof)
+	}
+	issuedAt, now := claims.IAT.Time, time.Now()
+	if issuedAt.After(now.Add(leeway)) || issuedAt.Before(now.Add(-maxAge-leeway)) {
+		return nil, fmt.Errorf("%w: iat is outside of the accepted window", ErrInvalidProof)
+	}
+
+	if accessToken != "" {
+		if subtle.ConstantTimeCompare([]byte(claims.ATH), []byte(AccessTokenHash(accessToken))) != 1 {
+			return nil, fmt.Errorf("%w: ath does not match the access token", ErrInvalidProof)
+		}
+	}
+
+	jkt, err := key.Thumbprint()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidProof, err)
+	}
+
+	// jti только уникален в пределах ключа, поэтому ключ кэша включает отпечаток.
+	if v.Replay != nil && v.Replay.Seen(jkt+":"+claims.JTI, issuedAt.Add(maxAge+leeway)) {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidProof, ErrReplayedProof)
+	}
+
+	return &Proof{
+		JKT:      jkt,
+		JTI:      claims.JTI,
+		IssuedAt: issuedAt,
+	}, nil
+}
+
+// SigningAlgorithms returns the algorithms accepted for proofs, the
+// dpop_signing_alg_values_supported of the discovery document.
+func SigningAlgorithms() []string {
+	return slices.Clone(signingMethods)
+}
+
+// AccessTokenHash returns the ath value for the access token.
+func AccessTokenHash(accessToken string) string {
+	sum := sha256.Sum256([]byte(accessToken))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// RequestURL returns the htu the client is expected to sign for the request: the URL
+// without query and fragment, with the scheme taken from the connection.
+func RequestURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host + r.URL.Path
+}
+
+// ExternalURL returns a RequestURL replacement for services behind a proxy that terminates
+// TLS or rewrites Host: the htu is baseURL, the public URL of the service, followed by the
+// path of the request.
+func ExternalURL(baseURL string) func(r *http.Request) string {
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	return func(r *http.Request) string {
+		return baseURL + r.URL.Path
+	}
+}
+
+// FromRequest returns the DPoP header of the request. More than one proof is an error.
+func FromRequest(r *http.Request) (string, error) {
+	values := r.Header.Values(HeaderName)
+	switch len(values) {
+	case 0:
+		return "", nil
+	case 1:
+		return values[0], nil
+	default:
+		return "", fmt.Errorf("%w: more than one DPoP header", ErrInvalidProof)
+	}
+}
+
+// sameURL compares URLs ignoring query, fragment and the case of scheme and host.
+func sameURL(htu, requestURL string) bool {
+	a, err := url.Parse(htu)
+	if err != nil {
+		return false
+	}
+	b, err := url.Parse(requestURL)
+	if err != nil {
+		return false
+	}
+
+	return strings.EqualFold(a.Scheme, b.Scheme) && strings.EqualFold(a.Host, b.Host) && a.Path == b.Path
+}
+
+// MemoryReplayCache is a ReplayCache for a single instance. Replicas behind a load
+// balancer each keep their own cache, so a proof could be replayed once per replica
+// within its lifetime.
+type MemoryReplayCache struct {
+	mu      sync.Mutex
+	entries map[string]time.Time
+	swept   time.Time
+}
+
+func NewMemoryReplayCache() *MemoryReplayCache {
+	return &MemoryReplayCache{
+		entries: make(map[string]time.Time),
+	}
+}
+
+func (c *MemoryReplayCache) Seen(key string, expiresAt time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(c.swept) > DefaultMaxAge {
+		for k, exp := range c.entries {
+			if exp.Before(now) {
+				delete(c.entries, k)
+			}
+		}
+		c.swept = now
+	}
+
+	if exp, ok := c.entries[key]; ok && exp.After(now) {
+		return true
+	}
+	c.entries[key] = expiresAt
+	return false
+}
@@ -0,0 +1,246 @@
+package dpop
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/BabyJhon/medods-test-task/pkg/jwk"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testMethod      = "POST"
+	testURL         = "https://auth.example.com/api/v1/oauth/token"
+	testAccessToken = "access-token"
+)
+
+func newKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func publicJWK(t *testing.T, key *ecdsa.PrivateKey) map[string]any {
+	t.Helper()
+	public, err := jwk.FromPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var header map[string]any
+	if err := json.Unmarshal(data, &header); err != nil {
+		t.Fatal(err)
+	}
+	return header
+}
+
+// newProof signs a valid proof for testMethod and testURL after applying modify to it.
+func newProof(t *testing.T, key *ecdsa.PrivateKey, jti string, modify func(token *jwt.Token, claims jwt.MapClaims)) string {
+	t.Helper()
+	claims := jwt.MapClaims{
+		"jti": jti,
+		"htm": testMethod,
+		"htu": testURL,
+		"iat": time.Now().Unix(),
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, claims)
+	token.Header["typ"] = proofType
+	token.Header["jwk"] = publicJWK(t, key)
+	if modify != nil {
+		modify(token, claims)
+	}
+
+	var signingKey any = key
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+		signingKey = []byte("shared secret")
+	}
+	proof, err := token.SignedString(signingKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return proof
+}
+
+func TestVerify(t *testing.T) {
+	key := newKey(t)
+
+	tests := []struct {
+		name        string
+		modify      func(token *jwt.Token, claims jwt.MapClaims)
+		accessToken string
+		valid       bool
+	}{
+		{name: "valid", valid: true},
+		{
+			name:        "valid with access token",
+			modify:      func(token *jwt.Token, claims jwt.MapClaims) { claims["ath"] = AccessTokenHash(testAccessToken) },
+			accessToken: testAccessToken,
+			valid:       true,
+		},
+		{
+			name: "htu with query and another case",
+			modify: func(token *jwt.Token, claims jwt.MapClaims) {
+				claims["htu"] = "HTTPS://Auth.Example.com/api/v1/oauth/token?x=1"
+			},
+			valid: true,
+		},
+		{
+			name:   "wrong typ",
+			modify: func(token *jwt.Token, claims jwt.MapClaims) { token.Header["typ"] = "JWT" },
+		},
+		{
+			name:   "no jwk",
+			modify: func(token *jwt.Token, claims jwt.MapClaims) { delete(token.Header, "jwk") },
+		},
+		{
+			name: "private key in jwk",
+			modify: func(token *jwt.Token, claims jwt.MapClaims) {
+				token.Header["jwk"].(map[string]any)["d"] = "private"
+			},
+		},
+		{
+			name:   "jwk of another key",
+			modify: func(token *jwt.Token, claims jwt.MapClaims) { token.Header["jwk"] = publicJWK(t, newKey(t)) },
+		},
+		{
+			name:   "symmetric algorithm",
+			modify: func(token *jwt.Token, claims jwt.MapClaims) { token.Method = jwt.SigningMethodHS256 },
+		},
+		{
+			name:   "no jti",
+			modify: func(token *jwt.Token, claims jwt.MapClaims) { delete(claims, "jti") },
+		},
+		{
+			name:   "htm mismatch",
+			modify: func(token *jwt.Token, claims jwt.MapClaims) { claims["htm"] = "GET" },
+		},
+		{
+			name: "htu mismatch",
+			modify: func(token *jwt.Token, claims jwt.MapClaims) {
+				claims["htu"] = "https://other.example.com/api/v1/oauth/token"
+			},
+		},
+		{
+			name: "htu path mismatch",
+			modify: func(token *jwt.Token, claims jwt.MapClaims) {
+				claims["htu"] = "https://auth.example.com/api/v1/refresh"
+			},
+		},
+		{
+			name:   "no iat",
+			modify: func(token *jwt.Token, claims jwt.MapClaims) { delete(claims, "iat") },
+		},
+		{
+			name: "iat too old",
+			modify: func(token *jwt.Token, claims jwt.MapClaims) {
+				claims["iat"] = time.Now().Add(-2 * DefaultMaxAge).Unix()
+			},
+		},
+		{
+			name:   "iat in the future",
+			modify: func(token *jwt.Token, claims jwt.MapClaims) { claims["iat"] = time.Now().Add(time.Minute).Unix() },
+		},
+		{
+			name:   "iat within leeway",
+			modify: func(token *jwt.Token, claims jwt.MapClaims) { claims["iat"] = time.Now().Add(DefaultLeeway / 2).Unix() },
+			valid:  true,
+		},
+		{
+			name:        "no ath with access token",
+			accessToken: testAccessToken,
+		},
+		{
+			name:        "ath mismatch",
+			modify:      func(token *jwt.Token, claims jwt.MapClaims) { claims["ath"] = AccessTokenHash("another token") },
+			accessToken: testAccessToken,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := &Verifier{Replay: NewMemoryReplayCache()}
+			proof := newProof(t, key, tt.name, tt.modify)
+
+			verified, err := v.Verify(proof, testMethod, testURL, tt.accessToken)
+			if !tt.valid {
+				if !errors.Is(err, ErrInvalidProof) {
+					t.Fatalf("got %v, want %v", err, ErrInvalidProof)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			public, _ := jwk.FromPublicKey(&key.PublicKey)
+			jkt, _ := public.Thumbprint()
+			if verified.JKT != jkt {
+				t.Errorf("JKT = %q, want %q", verified.JKT, jkt)
+			}
+		})
+	}
+}
+
+func TestVerifyReplay(t *testing.T) {
+	v := &Verifier{Replay: NewMemoryReplayCache()}
+	key, otherKey := newKey(t), newKey(t)
+
+	tests := []struct {
+		name     string
+		key      *ecdsa.PrivateKey
+		jti      string
+		replayed bool
+	}{
+		{name: "first use", key: key, jti: "1"},
+		{name: "same key and jti", key: key, jti: "1", replayed: true},
+		{name: "same key, another jti", key: key, jti: "2"},
+		// jti уникален только в пределах ключа, поэтому чужой jti не считается повтором.
+		{name: "another key, same jti", key: otherKey, jti: "1"},
+		{name: "another key replayed", key: otherKey, jti: "1", replayed: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := v.Verify(newProof(t, tt.key, tt.jti, nil), testMethod, testURL, "")
+			if tt.replayed != errors.Is(err, ErrReplayedProof) {
+				t.Fatalf("got %v, replayed %v", err, tt.replayed)
+			}
+			if !tt.replayed && err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestRequestURL(t *testing.T) {
+	// Прокси завершает TLS и передает запрос сервису по http на внутренний адрес.
+	req := httptest.NewRequest("POST", "http://auth:8000/api/v1/oauth/token?delivery=json", nil)
+
+	tests := []struct {
+		name       string
+		requestURL func(r *http.Request) string
+		want       string
+	}{
+		{name: "as seen by the service", requestURL: RequestURL, want: "http://auth:8000/api/v1/oauth/token"},
+		{name: "external", requestURL: ExternalURL("https://auth.example.com"), want: testURL},
+		{name: "external with trailing slash", requestURL: ExternalURL("https://auth.example.com/"), want: testURL},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.requestURL(req); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
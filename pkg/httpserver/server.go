@@ -3,6 +3,7 @@ package httpserver
 import (
 	"context"
 	"net/http"
+	"sync"
 	"time"
 )
 
@@ -14,21 +15,53 @@ const (
 )
 
 type Server struct {
+	mu         sync.Mutex
 	httpServer *http.Server
+	reloader   *certReloader
+	closed     bool
 }
 
 func (s *Server) Run(port string, handler http.Handler) error {
-	s.httpServer = &http.Server{
+	httpServer := &http.Server{
 		Addr:           ":" + port,
 		Handler:        handler,
 		ReadTimeout:    defaultReadTimeOut,
 		WriteTimeout:   defaultWriteTimeOut,
 		MaxHeaderBytes: DefaultMaxHeaderBytes,
 	}
+	if !s.start(httpServer, nil) {
+		return http.ErrServerClosed
+	}
+
+	return httpServer.ListenAndServe()
+}
+
+// start records the server for ShutDown, which may run concurrently with Run. It reports
+// false if ShutDown came first.
+func (s *Server) start(httpServer *http.Server, reloader *certReloader) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	return s.httpServer.ListenAndServe()
+	if s.closed {
+		return false
+	}
+	s.httpServer, s.reloader = httpServer, reloader
+	return true
 }
 
+// ShutDown stops accepting connections and waits for active requests until ctx is done.
+// A server that isn't running yet won't start.
 func (s *Server) ShutDown(ctx context.Context) error {
-	return s.httpServer.Shutdown(ctx)
+	s.mu.Lock()
+	s.closed = true
+	httpServer, reloader := s.httpServer, s.reloader
+	s.mu.Unlock()
+
+	if reloader != nil {
+		reloader.close()
+	}
+	if httpServer == nil {
+		return nil
+	}
+	return httpServer.Shutdown(ctx)
 }
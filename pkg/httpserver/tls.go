@@ -0,0 +1,286 @@
+package httpserver
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultReloadInterval = 30 * time.Second
+)
+
+type TLSConfig struct {
+	CertFile string
+	KeyFile  string
+	// ClientCAFile is a PEM bundle of CAs that client certificates are verified against.
+	// Without it client certificates are not requested unless ClientAuth says otherwise.
+	ClientCAFile string
+	// ClientAuth defaults to tls.RequireAndVerifyClientCert when ClientCAFile is set.
+	ClientAuth tls.ClientAuthType
+	// MinVersion defaults to TLS 1.2.
+	MinVersion uint16
+	// CipherSuites applies to TLS 1.2 only, TLS 1.3 suites are not configurable in Go.
+	CipherSuites []uint16
+	// ReloadInterval is how often the files are checked for changes, 30 seconds by default.
+	ReloadInterval time.Duration
+}
+
+// RunTLS is Run over TLS. The certificate, key and client CA files are re-read when they
+// change on disk, so renewed certificates are picked up without a restart.
+func (s *Server) RunTLS(port string, handler http.Handler, cfg TLSConfig) error {
+	reloader, err := newCertReloader(cfg)
+	if err != nil {
+		return err
+	}
+
+	minVersion := cfg.MinVersion
+	if minVersion == 0 {
+		minVersion = tls.VersionTLS12
+	}
+	clientAuth := cfg.ClientAuth
+	if clientAuth == tls.NoClientCert && cfg.ClientCAFile != "" {
+		clientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:   minVersion,
+		CipherSuites: cfg.CipherSuites,
+		ClientAuth:   clientAuth,
+		NextProtos:   []string{"h2", "http/1.1"},
+	}
+	tlsConfig.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		config := tlsConfig.Clone()
+		config.GetConfigForClient = nil
+		config.Certificates = []tls.Certificate{*reloader.certificate()}
+		config.ClientCAs = reloader.clientCAs()
+		return config, nil
+	}
+
+	httpServer := &http.Server{
+		Addr:           ":" + port,
+		Handler:        handler,
+		ReadTimeout:    defaultReadTimeOut,
+		WriteTimeout:   defaultWriteTimeOut,
+		MaxHeaderBytes: DefaultMaxHeaderBytes,
+		TLSConfig:      tlsConfig,
+	}
+	if !s.start(httpServer, reloader) {
+		return http.ErrServerClosed
+	}
+
+	go reloader.watch()
+
+	return httpServer.ListenAndServeTLS("", "")
+}
+
+// ClientCertificate returns the leaf certificate the client presented during the TLS
+// handshake. Whether it was verified depends on the server's ClientAuth.
+func ClientCertificate(r *http.Request) (*x509.Certificate, bool) {
+	if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+		return nil, false
+	}
+	return r.TLS.PeerCertificates[0], true
+}
+
+// CertificateThumbprint returns the base64url encoded SHA-256 hash of the DER certificate,
+// the x5t#S256 value of RFC 8705.
+func CertificateThumbprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func ParseTLSVersion(value string) (uint16, error) {
+	switch value {
+	case "":
+		return 0, nil
+	case "1.0":
+		return tls.VersionTLS10, nil
+	case "1.1":
+		return tls.VersionTLS11, nil
+	case "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, fmt.Errorf("unknown tls version %q", value)
+	}
+}
+
+// ParseCipherSuites parses a comma separated list of cipher suite names like
+// TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256. Insecure suites are rejected.
+func ParseCipherSuites(value string) ([]uint16, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	known := make(map[string]uint16)
+	for _, suite := range tls.CipherSuites() {
+		known[suite.Name] = suite.ID
+	}
+
+	var suites []uint16
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		id, ok := known[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown or insecure cipher suite %q", name)
+		}
+		suites = append(suites, id)
+	}
+
+	return suites, nil
+}
+
+func ParseClientAuth(value string) (tls.ClientAuthType, error) {
+	switch value {
+	case "", "none":
+		return tls.NoClientCert, nil
+	case "request":
+		return tls.RequestClientCert, nil
+	case "require":
+		return tls.RequireAnyClientCert, nil
+	case "verify_if_given":
+		return tls.VerifyClientCertIfGiven, nil
+	case "require_and_verify":
+		return tls.RequireAndVerifyClientCert, nil
+	default:
+		return 0, fmt.Errorf("unknown client auth %q", value)
+	}
+}
+
+type certReloader struct {
+	cfg     TLSConfig
+	mu      sync.RWMutex
+	cert    *tls.Certificate
+	pool    *x509.CertPool
+	modTime time.Time
+	stop    chan struct{}
+	once    sync.Once
+}
+
+func newCertReloader(cfg TLSConfig) (*certReloader, error) {
+	if cfg.CertFile == "" || cfg.KeyFile == "" {
+		return nil, errors.New("tls certificate and key files are required")
+	}
+	if cfg.ReloadInterval == 0 {
+		cfg.ReloadInterval = defaultReloadInterval
+	}
+
+	r := &certReloader{
+		cfg:  cfg,
+		stop: make(chan struct{}),
+	}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (r *certReloader) certificate() *tls.Certificate {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert
+}
+
+func (r *certReloader) clientCAs() *x509.CertPool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.pool
+}
+
+func (r *certReloader) load() error {
+	modTime, err := r.latestModTime()
+	if err != nil {
+		return err
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.cfg.CertFile, r.cfg.KeyFile)
+	if err != nil {
+		return fmt.Errorf("load tls certificate: %w", err)
+	}
+
+	var pool *x509.CertPool
+	if r.cfg.ClientCAFile != "" {
+		data, err := os.ReadFile(r.cfg.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("read client ca file: %w", err)
+		}
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return fmt.Errorf("no certificates in client ca file %s", r.cfg.ClientCAFile)
+		}
+	}
+
+	r.mu.Lock()
+	r.cert, r.pool, r.modTime = &cert, pool, modTime
+	r.mu.Unlock()
+
+	return nil
+}
+
+func (r *certReloader) latestModTime() (time.Time, error) {
+	var latest time.Time
+	for _, path := range []string{r.cfg.CertFile, r.cfg.KeyFile, r.cfg.ClientCAFile} {
+		if path == "" {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
+
+// watch reloads the files when any of them changes. A broken file (for example, a
+// certificate written before its key) keeps the previous certificate in use until the
+// next successful reload.
+func (r *certReloader) watch() {
+	ticker := time.NewTicker(r.cfg.ReloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+			modTime, err := r.latestModTime()
+			if err != nil {
+				logrus.Errorf("failed to check tls files: %s", err.Error())
+				continue
+			}
+
+			r.mu.RLock()
+			changed := modTime.After(r.modTime)
+			r.mu.RUnlock()
+			if !changed {
+				continue
+			}
+
+			if err := r.load(); err != nil {
+				logrus.Errorf("failed to reload tls files: %s", err.Error())
+				continue
+			}
+			logrus.Print("tls certificate reloaded")
+		}
+	}
+}
+
+func (r *certReloader) close() {
+	r.once.Do(func() { close(r.stop) })
+}
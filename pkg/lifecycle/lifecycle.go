@@ -0,0 +1,130 @@
+// Package lifecycle starts the components of an application in registration order and
+// stops them in reverse order within an overall shutdown deadline.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const DefaultShutdownTimeout = 30 * time.Second
+
+type Config struct {
+	// ShutdownTimeout bounds the whole shutdown, drain delay included. DefaultShutdownTimeout
+	// if zero.
+	ShutdownTimeout time.Duration
+	// DrainDelay is how long readiness fails before the first component is stopped, so load
+	// balancers stop sending new requests while the servers still accept them.
+	DrainDelay time.Duration
+}
+
+// Component is a part of the application such as an HTTP server, a worker or a DB pool.
+type Component struct {
+	Name string
+	// Start must return once the component is running, long-running work goes to its own
+	// goroutines. Nil for components that are ready when added, like an open DB pool.
+	Start func(ctx context.Context) error
+	// Stop gets the shutdown deadline in ctx. Nil if there is nothing to stop.
+	Stop func(ctx context.Context) error
+}
+
+type Manager struct {
+	cfg        Config
+	components []Component
+	ready      atomic.Bool
+	failures   chan error
+}
+
+func New(cfg Config) *Manager {
+	if cfg.ShutdownTimeout == 0 {
+		cfg.ShutdownTimeout = DefaultShutdownTimeout
+	}
+	return &Manager{
+		cfg:      cfg,
+		failures: make(chan error, 1),
+	}
+}
+
+// Add registers a component. Components are started in the order they are added, so
+// dependencies go first.
+func (m *Manager) Add(component Component) {
+	m.components = append(m.components, component)
+}
+
+// Ready reports whether all components have started and shutdown hasn't begun.
+func (m *Manager) Ready() bool {
+	return m.ready.Load()
+}
+
+// Fail reports that a running component has stopped working, which shuts the application
+// down. Only the first failure is kept.
+func (m *Manager) Fail(name string, err error) {
+	select {
+	case m.failures <- fmt.Errorf("%s: %w", name, err):
+	default:
+	}
+}
+
+// Run starts the components, waits until ctx is done or a component fails and shuts the
+// components down. If a component fails to start, the ones already started are stopped.
+func (m *Manager) Run(ctx context.Context) error {
+	for i, component := range m.components {
+		if component.Start == nil {
+			continue
+		}
+		if err := component.Start(ctx); err != nil {
+			err = fmt.Errorf("failed to start %s: %w", component.Name, err)
+			return errors.Join(err, m.stop(m.components[:i]))
+		}
+		logrus.Printf("%s started", component.Name)
+	}
+	m.ready.Store(true)
+
+	var failure error
+	select {
+	case <-ctx.Done():
+	case failure = <-m.failures:
+		logrus.Errorf("%s, shutting down", failure.Error())
+	}
+
+	return errors.Join(failure, m.stop(m.components))
+}
+
+// stop flips readiness, waits for the drain delay and stops the components in reverse
+// order. A component that misses the deadline doesn't keep the others from stopping: they
+// get the expired context and may release what they can.
+func (m *Manager) stop(components []Component) error {
+	m.ready.Store(false)
+	logrus.Print("shutting down")
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.cfg.ShutdownTimeout)
+	defer cancel()
+
+	if m.cfg.DrainDelay > 0 {
+		timer := time.NewTimer(m.cfg.DrainDelay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+		}
+	}
+
+	var errs []error
+	for i := len(components) - 1; i >= 0; i-- {
+		component := components[i]
+		if component.Stop == nil {
+			continue
+		}
+		if err := component.Stop(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("failed to stop %s: %w", component.Name, err))
+			continue
+		}
+		logrus.Printf("%s stopped", component.Name)
+	}
+	return errors.Join(errs...)
+}
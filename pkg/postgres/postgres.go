@@ -9,7 +9,13 @@ import (
 
 
 const (
-	SessionTable = "refresh_sessions"
+	SessionTable           = "refresh_sessions"
+	AuthorizationCodeTable = "authorization_codes"
+	OAuthClientTable       = "oauth_clients"
+	RoleTable              = "roles"
+	UserRoleTable          = "user_roles"
+	SecurityEventTable     = "security_events"
+	WebhookDeliveryTable   = "webhook_deliveries"
 )
 
 type Config struct {